@@ -0,0 +1,186 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/log"
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+type ConsensusMode string
+
+const (
+	ConsensusFlag   ConsensusMode = "flag"   // Расхождения только пишутся в лог.
+	ConsensusReject ConsensusMode = "reject" // Расхождения не позволяют сохранить календарь.
+)
+
+// Consensus сверяет несколько независимых источников (парсеров) между собой по дням.
+// Календарь берется из первого источника, который вернул данные, остальные используются для сверки.
+//
+// Источники по-разному размечают одни и те же дни, поэтому перед сравнением тип дня нормализуется:
+//   - праздник и выходной не различаются: например, перенесенный выходной consultant.ru отмечает как праздник,
+//     а superjob.ru — как выходной, а для праздника, выпавшего на выходной, наоборот;
+//   - нерабочий день с сохранением зарплаты (store.NonWorking) не сравнивается: superjob.ru таких дней не знает
+//     и показывает их как обычные или предпраздничные.
+//
+// Расхождение в Working или день, который вернул только один из источников, — признак того, что один из парсеров
+// сломался. В режиме ConsensusFlag такие дни пишутся в лог, в режиме ConsensusReject GetYear возвращает
+// *ConsensusError, и календарь не сохраняется.
+// Расхождение только в сокращенных днях всегда пишется в лог как предупреждение.
+type Consensus struct {
+	Sources []Source
+	Mode    ConsensusMode
+}
+
+// DayConflict — день, в котором источники расходятся.
+type DayConflict struct {
+	Date    string
+	Values  map[string]store.Day // Название источника => день.
+	Missing string               // Название источника, который не вернул этот день.
+}
+
+func (c DayConflict) String() string {
+	names := make([]string, 0, len(c.Values))
+	for name := range c.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vals := make([]string, len(names))
+	for i, name := range names {
+		vals[i] = fmt.Sprintf("%s: %s", name, dayString(c.Values[name]))
+	}
+	if c.Missing != "" {
+		vals = append(vals, fmt.Sprintf("%s: missing", c.Missing))
+	}
+	return fmt.Sprintf("%s (%s)", c.Date, strings.Join(vals, ", "))
+}
+
+// ConsensusError — источники расходятся в рабочих днях (режим ConsensusReject).
+type ConsensusError struct {
+	Year      int
+	Conflicts []DayConflict
+}
+
+func (e *ConsensusError) Error() string {
+	strs := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		strs[i] = c.String()
+	}
+	return fmt.Sprintf("sources disagree on working days in %d: %s", e.Year, shortList(strs, 5))
+}
+
+func (c *Consensus) Name() string {
+	names := make([]string, len(c.Sources))
+	for i, src := range c.Sources {
+		names[i] = sourceName(src)
+	}
+	return "consensus:" + strings.Join(names, "+")
+}
+
+// External реализует calendar.External: данные консенсуса проверяются так же, как данные парсеров.
+func (c *Consensus) External() bool {
+	return true
+}
+
+func (c *Consensus) GetYear(y int) (store.Months, error) {
+	var (
+		base     store.Months
+		baseName string
+		errs     []string
+		checked  int // Сколько источников сверено с base.
+	)
+
+	for _, src := range c.Sources {
+		months, err := src.GetYear(y)
+		if err != nil {
+			log.Printf("[WARN] calendar/consensus source %s failed, year %d: %v", sourceName(src), y, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", sourceName(src), err))
+			continue
+		}
+
+		if base == nil {
+			base, baseName = months, sourceName(src)
+			if len(errs) > 0 {
+				log.Printf("[WARN] calendar/consensus year %d: %s is used instead of the failed source(s)",
+					y, baseName)
+			}
+			continue
+		}
+
+		checked++
+		conflicts := c.compare(y, baseName, base, sourceName(src), months)
+		if len(conflicts) > 0 && c.Mode == ConsensusReject {
+			return nil, &ConsensusError{Year: y, Conflicts: conflicts}
+		}
+	}
+
+	if base == nil {
+		return nil, fmt.Errorf("all sources failed: %s", strings.Join(errs, "; "))
+	}
+	if checked == 0 && len(c.Sources) > 1 {
+		log.Printf("[WARN] calendar/consensus year %d: only %s returned data, the calendar is not cross-checked",
+			y, baseName)
+	}
+	return base, nil
+}
+
+// compare сравнивает по дням данные двух источников и возвращает дни, в которых они расходятся в Working,
+// и дни, которые вернул только один из них.
+func (c *Consensus) compare(y int, name1 string, m1 store.Months, name2 string, m2 store.Months) []DayConflict {
+	var (
+		conflicts []DayConflict
+		missing   []string
+	)
+	compared := 0
+
+	for date := store.NewDate(y, time.January, 1); date.Year() == y; date = date.AddDate(0, 0, 1) {
+		d1, ok1 := m1[date.Month()][date.Day()]
+		d2, ok2 := m2[date.Month()][date.Day()]
+		switch {
+		case !ok1 && !ok2:
+			continue
+		case !ok1:
+			conflicts = append(conflicts, DayConflict{
+				Date: date.Format(store.DateLayout), Values: map[string]store.Day{name2: d2}, Missing: name1,
+			})
+			missing = append(missing, date.Format(store.DateLayout))
+			continue
+		case !ok2:
+			conflicts = append(conflicts, DayConflict{
+				Date: date.Format(store.DateLayout), Values: map[string]store.Day{name1: d1}, Missing: name2,
+			})
+			missing = append(missing, date.Format(store.DateLayout))
+			continue
+		}
+		compared++
+
+		conflict := DayConflict{
+			Date:   date.Format(store.DateLayout),
+			Values: map[string]store.Day{name1: d1, name2: d2},
+		}
+
+		switch {
+		case d1.Working != d2.Working:
+			log.Printf("[WARN] calendar/consensus working day mismatch: %s", conflict)
+			conflicts = append(conflicts, conflict)
+		case isShortDay(d1) != isShortDay(d2) && d1.Type != store.NonWorking && d2.Type != store.NonWorking:
+			log.Printf("[WARN] calendar/consensus pre-holiday day mismatch: %s", conflict)
+		}
+	}
+
+	if len(missing) > 0 {
+		log.Printf("[WARN] calendar/consensus year %d: %d day(s) returned by only one of %s and %s (%s)",
+			y, len(missing), name1, name2, shortList(missing, 5))
+	}
+	log.Printf("[INFO] calendar/consensus year %d: %s and %s compared, %d day(s), %d conflict(s)",
+		y, name1, name2, compared, len(conflicts))
+	return conflicts
+}
+
+func isShortDay(d store.Day) bool {
+	return d.Working && d.Type == store.PreHoliday
+}
@@ -0,0 +1,147 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Различия consultant.ru и superjob.ru за май 2021 года.
+var (
+	consultantMay = store.Months{time.May: {
+		1: {WeekDay: store.Saturday, Working: false, Type: store.Weekend},
+		3: {WeekDay: store.Monday, Working: false, Type: store.Holiday},
+		4: {WeekDay: store.Tuesday, Working: true, Type: store.NonWorking},
+		7: {WeekDay: store.Friday, Working: true, Type: store.NonWorking},
+		8: {WeekDay: store.Saturday, Working: false, Type: store.Weekend},
+	}}
+	superJobMay = store.Months{time.May: {
+		1: {WeekDay: store.Saturday, Working: false, Type: store.Holiday, Desc: "Праздник Весны и Труда"},
+		3: {WeekDay: store.Monday, Working: false, Type: store.Weekend},
+		4: {WeekDay: store.Tuesday, Working: true, Type: store.Normal},
+		7: {WeekDay: store.Friday, Working: true, Type: store.PreHoliday},
+		8: {WeekDay: store.Saturday, Working: false, Type: store.Weekend},
+	}}
+)
+
+func TestConsensus_GetYear(t *testing.T) {
+	c := &Consensus{
+		Sources: []Source{
+			namedSrc{SrcMock{2021: consultantMay}, "consultant"},
+			namedSrc{SrcMock{2021: superJobMay}, "superjob"},
+		},
+		Mode: ConsensusReject,
+	}
+	assert.Equal(t, "consensus:consultant+superjob", c.Name())
+
+	months, err := c.GetYear(2021)
+	require.NoError(t, err)
+	assert.Equal(t, consultantMay, months, "data must be taken from the first source")
+}
+
+func TestConsensus_GetYear_conflict(t *testing.T) {
+	broken := superJobMay.Copy()
+	broken[time.May][3] = store.Day{WeekDay: store.Monday, Working: true, Type: store.Normal}
+
+	c := &Consensus{
+		Sources: []Source{
+			namedSrc{SrcMock{2021: consultantMay}, "consultant"},
+			namedSrc{SrcMock{2021: broken}, "superjob"},
+		},
+		Mode: ConsensusFlag,
+	}
+
+	months, err := c.GetYear(2021)
+	require.NoError(t, err)
+	assert.Equal(t, consultantMay, months)
+
+	c.Mode = ConsensusReject
+	_, err = c.GetYear(2021)
+
+	var cErr *ConsensusError
+	require.ErrorAs(t, err, &cErr)
+	assert.Equal(t, []DayConflict{{
+		Date: "2021-05-03",
+		Values: map[string]store.Day{
+			"consultant": {WeekDay: store.Monday, Working: false, Type: store.Holiday},
+			"superjob":   {WeekDay: store.Monday, Working: true, Type: store.Normal},
+		},
+	}}, cErr.Conflicts)
+	assert.EqualError(t, err, "sources disagree on working days in 2021: "+
+		"2021-05-03 (consultant: non-working holiday, superjob: working normal)")
+}
+
+func TestConsensus_GetYear_missingDays(t *testing.T) {
+	// Второй парсер сломался, но не вернул ошибку: данных нет, сверять нечего.
+	c := &Consensus{
+		Sources: []Source{
+			namedSrc{SrcMock{2021: consultantMay}, "consultant"},
+			namedSrc{SrcMock{2021: store.Months{}}, "superjob"},
+		},
+		Mode: ConsensusFlag,
+	}
+
+	months, err := c.GetYear(2021)
+	require.NoError(t, err)
+	assert.Equal(t, consultantMay, months)
+
+	c.Mode = ConsensusReject
+	_, err = c.GetYear(2021)
+
+	var cErr *ConsensusError
+	require.ErrorAs(t, err, &cErr)
+	assert.Len(t, cErr.Conflicts, 5)
+	assert.Equal(t, DayConflict{
+		Date:    "2021-05-01",
+		Values:  map[string]store.Day{"consultant": consultantMay[time.May][1]},
+		Missing: "superjob",
+	}, cErr.Conflicts[0])
+	assert.ErrorContains(t, err, "2021-05-01 (consultant: non-working weekend, superjob: missing)")
+}
+
+func TestConsensus_GetYear_sourceFailed(t *testing.T) {
+	c := &Consensus{
+		Sources: []Source{
+			namedSrc{SrcMock{}, "consultant"},
+			namedSrc{SrcMock{2021: superJobMay}, "superjob"},
+		},
+		Mode: ConsensusReject,
+	}
+
+	months, err := c.GetYear(2021)
+	require.NoError(t, err)
+	assert.Equal(t, superJobMay, months, "the first available source must be used")
+
+	_, err = c.GetYear(2022)
+	assert.ErrorContains(t, err, "all sources failed")
+}
+
+func TestProcessor_UpdateCalendar_consensusRejected(t *testing.T) {
+	broken := superJobMay.Copy()
+	broken[time.May][4] = store.Day{WeekDay: store.Tuesday, Working: false, Type: store.Holiday}
+
+	st := StoreMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Src: []Source{
+			SrcMock{2021: {time.May: {4: {WeekDay: store.Tuesday, Working: true, Type: store.Normal}}}},
+			&Consensus{
+				Sources: []Source{
+					namedSrc{SrcMock{2021: consultantMay}, "consultant"},
+					namedSrc{SrcMock{2021: broken}, "superjob"},
+				},
+				Mode: ConsensusReject,
+			},
+		},
+		Store: st,
+	})
+
+	_, err := p.UpdateCalendar(2021)
+	var vErr *ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Len(t, vErr.Problems, 1)
+	assert.Contains(t, vErr.Problems[0], "source consensus:consultant+superjob: sources disagree")
+	assert.Empty(t, st)
+}
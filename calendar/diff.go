@@ -0,0 +1,93 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+type ChangeKind string
+
+const (
+	DayAdded   ChangeKind = "added"
+	DayRemoved ChangeKind = "removed"
+	DayChanged ChangeKind = "changed"
+)
+
+// DayChange — изменение одного дня. Для добавленного дня Old=nil, для удаленного New=nil.
+type DayChange struct {
+	Date string     `json:"date"` // store.DateLayout.
+	Kind ChangeKind `json:"kind"`
+	Old  *store.Day `json:"old,omitempty"`
+	New  *store.Day `json:"new,omitempty"`
+}
+
+// Diff — изменения календаря за год после синхронизации.
+type Diff struct {
+	Calendar string      `json:"calendar,omitempty"` // ProcOpts.Name, пустое — основной календарь.
+	Year     int         `json:"year"`
+	Created  bool        `json:"created,omitempty"` // Год сохранен впервые, все дни в Changes — добавленные.
+	Changes  []DayChange `json:"changes"`
+}
+
+// Changed возвращает true, если синхронизация изменила сохраненный календарь.
+func (d Diff) Changed() bool {
+	return len(d.Changes) > 0
+}
+
+// DiffYears сравнивает два календаря года y по дням. Изменения упорядочены по дате.
+func DiffYears(y int, old, new store.Months) []DayChange {
+	changes := make([]DayChange, 0)
+
+	for date := store.NewDate(y, time.January, 1); date.Year() == y; date = date.AddDate(0, 0, 1) {
+		oldDay, oldOk := old[date.Month()][date.Day()]
+		newDay, newOk := new[date.Month()][date.Day()]
+
+		ch := DayChange{Date: date.Format(store.DateLayout)}
+		switch {
+		case !oldOk && !newOk:
+			continue
+		case !oldOk:
+			ch.Kind, ch.New = DayAdded, &newDay
+		case !newOk:
+			ch.Kind, ch.Old = DayRemoved, &oldDay
+		case oldDay != newDay:
+			ch.Kind, ch.Old, ch.New = DayChanged, &oldDay, &newDay
+		default:
+			continue
+		}
+		changes = append(changes, ch)
+	}
+
+	return changes
+}
+
+func (c DayChange) String() string {
+	switch c.Kind {
+	case DayAdded:
+		return fmt.Sprintf("%s added: %s", c.Date, dayString(*c.New))
+	case DayRemoved:
+		return fmt.Sprintf("%s removed: %s", c.Date, dayString(*c.Old))
+	default:
+		return fmt.Sprintf("%s changed: %s -> %s", c.Date, dayString(*c.Old), dayString(*c.New))
+	}
+}
+
+func dayString(d store.Day) string {
+	var sb strings.Builder
+	if d.Working {
+		sb.WriteString("working")
+	} else {
+		sb.WriteString("non-working")
+	}
+	fmt.Fprintf(&sb, " %s", d.Type)
+	if d.Desc != "" {
+		fmt.Fprintf(&sb, " %q", d.Desc)
+	}
+	if d.Provisional {
+		sb.WriteString(" provisional")
+	}
+	return sb.String()
+}
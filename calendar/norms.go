@@ -0,0 +1,99 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// Norm — норма рабочего времени за период при 40-, 36- и 24-часовой рабочей неделе.
+// Считается так же, как в производственных календарях Консультанта: продолжительность рабочего дня равна
+// недельной норме, деленной на 5, а каждый предпраздничный день короче на один час.
+type Norm struct {
+	CalendarDays   int     `json:"calendarDays"`
+	WorkingDays    int     `json:"workingDays"`
+	NonWorkingDays int     `json:"nonWorkingDays"`
+	Hours40        float64 `json:"hours40"`
+	Hours36        float64 `json:"hours36"`
+	Hours24        float64 `json:"hours24"`
+}
+
+// YearNorms — нормы рабочего времени за год и его части.
+type YearNorms struct {
+	Year     Norm                `json:"year"`
+	Halves   map[int]Norm        `json:"halves"`   // Ключ — номер полугодия (1, 2).
+	Quarters map[int]Norm        `json:"quarters"` // Ключ — номер квартала (1-4).
+	Months   map[time.Month]Norm `json:"months"`
+}
+
+// MonthNorm считает норму рабочего времени за месяц.
+func MonthNorm(days store.Days) Norm {
+	var st Stats
+	for _, d := range days {
+		st.Add(d)
+	}
+	return st.Norm()
+}
+
+// NewYearNorms считает нормы рабочего времени за год, полугодия, кварталы и месяцы.
+func NewYearNorms(months store.Months) YearNorms {
+	var year Stats
+	halves := make(map[int]*Stats, 2)
+	quarters := make(map[int]*Stats, 4)
+	byMonth := make(map[time.Month]*Stats, 12)
+
+	for mon, days := range months {
+		h := (int(mon)-1)/6 + 1
+		q := (int(mon)-1)/3 + 1
+		if halves[h] == nil {
+			halves[h] = &Stats{}
+		}
+		if quarters[q] == nil {
+			quarters[q] = &Stats{}
+		}
+		byMonth[mon] = &Stats{}
+
+		for _, d := range days {
+			year.Add(d)
+			halves[h].Add(d)
+			quarters[q].Add(d)
+			byMonth[mon].Add(d)
+		}
+	}
+
+	n := YearNorms{
+		Year:     year.Norm(),
+		Halves:   make(map[int]Norm, len(halves)),
+		Quarters: make(map[int]Norm, len(quarters)),
+		Months:   make(map[time.Month]Norm, len(byMonth)),
+	}
+	for h, st := range halves {
+		n.Halves[h] = st.Norm()
+	}
+	for q, st := range quarters {
+		n.Quarters[q] = st.Norm()
+	}
+	for mon, st := range byMonth {
+		n.Months[mon] = st.Norm()
+	}
+	return n
+}
+
+// Norm возвращает норму рабочего времени для дней, учтенных в статистике.
+func (st Stats) Norm() Norm {
+	return Norm{
+		CalendarDays:   st.CalendarDays,
+		WorkingDays:    st.Working,
+		NonWorkingDays: st.NonWorking,
+		Hours40:        normHours(st, 40),
+		Hours36:        normHours(st, 36),
+		Hours24:        normHours(st, 24),
+	}
+}
+
+// normHours считает норму часов при рабочей неделе weekHours.
+// Вычисления ведутся в десятых долях часа, чтобы не накапливать ошибку округления (36/5 = 7,2 часа).
+func normHours(st Stats, weekHours int) float64 {
+	tenths := st.Working*weekHours*2 - st.PreHoliday*10
+	return float64(tenths) / 10
+}
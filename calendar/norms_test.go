@@ -0,0 +1,58 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewYearNorms(t *testing.T) {
+	// 2021 год по данным Консультанта: 247 рабочих дней, 1972 часа при 40-часовой неделе.
+	y := makeYear(2021)
+	setDays(y, store.Holiday, "2021-01-01", "2021-01-04", "2021-01-05", "2021-01-06", "2021-01-07", "2021-01-08",
+		"2021-02-22", "2021-02-23", "2021-03-08", "2021-05-03", "2021-05-10", "2021-06-14", "2021-11-04",
+		"2021-11-05", "2021-12-31")
+	setDays(y, store.NonWorking, "2021-05-04", "2021-05-05", "2021-05-06", "2021-05-07")
+	setDays(y, store.PreHoliday, "2021-02-20", "2021-04-30", "2021-06-11", "2021-11-03")
+
+	n := NewYearNorms(y)
+
+	expYear := Norm{
+		CalendarDays:   365,
+		WorkingDays:    247,
+		NonWorkingDays: 118,
+		Hours40:        1972,
+		Hours36:        1774.4,
+		Hours24:        1181.6,
+	}
+	assert.Equal(t, expYear, n.Year)
+
+	assert.Equal(t, 56, n.Quarters[1].WorkingDays)
+	assert.Equal(t, 62, n.Quarters[2].WorkingDays)
+	assert.Equal(t, 118, n.Halves[1].WorkingDays)
+	assert.Equal(t, 129, n.Halves[2].WorkingDays)
+
+	expFeb := Norm{
+		CalendarDays:   28,
+		WorkingDays:    19,
+		NonWorkingDays: 9,
+		Hours40:        151,
+		Hours36:        135.8,
+		Hours24:        90.2,
+	}
+	assert.Equal(t, expFeb, n.Months[time.February])
+	assert.Equal(t, expFeb, MonthNorm(y[time.February]))
+}
+
+// setDays меняет тип указанных дней. Рабочими считаются предпраздничные и нерабочие (noWork) дни.
+func setDays(months store.Months, typ store.DayType, dates ...string) {
+	for _, s := range dates {
+		date, _ := store.ParseDate(s)
+		day := months[date.Month()][date.Day()]
+		day.Type = typ
+		day.Working = typ == store.PreHoliday || typ == store.NonWorking
+		months[date.Month()][date.Day()] = day
+	}
+}
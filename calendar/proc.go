@@ -2,6 +2,7 @@ package calendar
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -15,14 +16,43 @@ type Source interface {
 	GetYear(y int) (store.Months, error)
 }
 
+// Named — источник, у которого есть название для истории изменений календаря.
+// Для остальных источников используется название типа.
+type Named interface {
+	Name() string
+}
+
 type Store interface {
+	FindYear(y int) (store.Months, bool)
 	PutYear(y int, data store.Months) error
 }
 
+// RevisionStore — хранилище, которое ведет историю изменений календаря.
+// Если Store его реализует, вместе с календарем сохраняется список источников, из которых он собран.
+type RevisionStore interface {
+	PutRevision(y int, rev store.Revision) error
+}
+
+// MetaStore — хранилище, которое хранит статус года и источники полей его дней.
+// Если Store его реализует, данные недоступного внешнего источника можно взять из сохраненного года, см. UpdateCalendar.
+type MetaStore interface {
+	FindYearMeta(y int) (*store.YearMeta, bool)
+	FindProvenance(y int) (store.Provenance, bool)
+}
+
+// Notifier получает изменения календаря после каждой синхронизации, которая изменила хранилище.
+// Notify вызывается синхронно, поэтому не должен надолго задерживать синхронизацию.
+type Notifier interface {
+	Notify(diff Diff)
+}
+
 type ProcOpts struct {
-	Src      []Source  // Упорядоченный список источников календарей.
-	Store    Store     // Куда сохранять итоговый календарь (необязательно, если нужен только метод MakeCalendar).
-	UpdateAt time.Time // Используется только время, остальное игнорируется.
+	Name      string     // Название календаря для логов и ошибок, пустое — основной календарь.
+	Src       []Source   // Упорядоченный список источников календарей.
+	Store     Store      // Куда сохранять итоговый календарь (необязательно, если нужен только метод MakeCalendar).
+	UpdateAt  time.Time  // Используется только время, остальное игнорируется.
+	Notifiers []Notifier // Кого уведомлять об изменениях календаря.
+	Validator *Validator // Проверки перед сохранением календаря, nil — без проверок.
 }
 
 type Processor struct {
@@ -40,7 +70,7 @@ func NewProcessor(opts ProcOpts) *Processor {
 
 // RunUpdates раз в сутки (UpdateAt) обновляет календари за текущий и следующий год.
 func (p *Processor) RunUpdates() {
-	log.Printf("[INFO] calendar/proc starting daily sync at %s", p.UpdateAt.Format("15:04:05"))
+	log.Printf("[INFO] %s starting daily sync at %s", p.logName(), p.UpdateAt.Format("15:04:05"))
 
 	t := time.NewTimer(p.untilNextRun())
 	for {
@@ -66,12 +96,19 @@ func (p *Processor) Shutdown(ctx context.Context) error {
 				return nil
 			}
 		case <-ctx.Done():
-			log.Printf("[WARN] calendar/proc shutdown timeout")
+			log.Printf("[WARN] %s shutdown timeout", p.logName())
 			return ctx.Err()
 		}
 	}
 }
 
+func (p *Processor) logName() string {
+	if p.Name == "" {
+		return "calendar/proc"
+	}
+	return fmt.Sprintf("calendar/proc[%s]", p.Name)
+}
+
 func (p *Processor) untilNextRun() time.Duration {
 	now := time.Now()
 
@@ -91,25 +128,86 @@ func (p *Processor) untilNextRun() time.Duration {
 func (p *Processor) UpdateCurrentYears() {
 	y := time.Now().Year()
 
-	log.Printf("[INFO] calendar/proc daily sync, year %d...", y)
-	if err := p.UpdateCalendar(y); err != nil {
-		log.Printf("[WARN] calendar/proc cannot update %d: %+v", y, err)
+	log.Printf("[INFO] %s daily sync, year %d...", p.logName(), y)
+	if _, err := p.UpdateCalendar(y); err != nil {
+		log.Printf("[WARN] %s cannot update %d: %+v", p.logName(), y, err)
 	}
 
-	log.Printf("[INFO] calendar/proc daily sync, year %d...", y+1)
-	if err := p.UpdateCalendar(y + 1); err != nil {
-		log.Printf("[WARN] calendar/proc cannot update %d: %+v", y+1, err)
+	log.Printf("[INFO] %s daily sync, year %d...", p.logName(), y+1)
+	if _, err := p.UpdateCalendar(y + 1); err != nil {
+		log.Printf("[WARN] %s cannot update %d: %+v", p.logName(), y+1, err)
 	}
 }
 
-func (p *Processor) UpdateCalendar(y int) error {
-	cal := p.MakeCalendar(y)
-	if len(cal) > 0 {
-		if err := p.Store.PutYear(y, cal); err != nil {
-			return fmt.Errorf("calendar/proc cannot store year %d: %w", y, err)
+// UpdateCalendar собирает календарь на год y, сохраняет его и возвращает отличия от ранее сохраненного.
+// Если ни один источник не вернул данных, хранилище не меняется, и Diff будет пустым.
+// Если календарь не прошел проверки Validator, хранилище не меняется, и возвращается *ValidationError.
+//
+// Внешний источник (External), который вернул ошибку или ни одного месяца, пропускается. Если при этом задан
+// Validator, а сохраненный год содержит данные внешних источников (его статус не store.StatusGenerated), вместо
+// данных источника берутся сохраненные дни, и остальные источники накладываются поверх них: пока сайт недоступен,
+// постановления и переопределения могут добавлять данные. Если же остальные источники перестали возвращать
+// данные, которые вошли в сохраненный год (например, удалено переопределение), или хранилище не хранит источники
+// дней (MetaStore), без недоступного источника год не собрать, и возвращается *ValidationError.
+func (p *Processor) UpdateCalendar(y int) (Diff, error) {
+	diff := Diff{Calendar: p.Name, Year: y, Changes: []DayChange{}}
+
+	prev, found := p.Store.FindYear(y)
+	var kept *keptYear
+	if found && p.Validator != nil {
+		kept = p.keptYear(y, prev)
+	}
+	cal, prov, sources, problems := p.makeCalendar(y, kept)
+	if len(cal) == 0 && len(problems) == 0 {
+		return diff, nil
+	}
+
+	diff.Created = !found
+	diff.Changes = DiffYears(y, prev, cal)
+
+	if p.Validator != nil && found {
+		problems = append(problems, p.Validator.checkChanges(diff.Changes)...)
+	}
+	if len(problems) > 0 {
+		for _, pr := range problems {
+			log.Printf("[WARN] %s year %d rejected: %s", p.logName(), y, pr)
+		}
+		return Diff{}, &ValidationError{Calendar: p.Name, Year: y, Problems: problems}
+	}
+
+	var err error
+	if rs, ok := p.Store.(RevisionStore); ok {
+		rev := store.NewRevision(cal, sources)
+		rev.Provenance = prov
+		err = rs.PutRevision(y, rev)
+	} else {
+		err = p.Store.PutYear(y, cal)
+	}
+	if err != nil {
+		return Diff{}, fmt.Errorf("%s cannot store year %d: %w", p.logName(), y, err)
+	}
+
+	p.logDiff(diff)
+	if diff.Changed() {
+		for _, n := range p.Notifiers {
+			n.Notify(diff)
+		}
+	}
+	return diff, nil
+}
+
+func (p *Processor) logDiff(diff Diff) {
+	switch {
+	case diff.Created:
+		log.Printf("[INFO] %s year %d stored for the first time, %d day(s)", p.logName(), diff.Year, len(diff.Changes))
+	case !diff.Changed():
+		log.Printf("[INFO] %s year %d: no changes", p.logName(), diff.Year)
+	default:
+		log.Printf("[INFO] %s year %d: %d day(s) changed", p.logName(), diff.Year, len(diff.Changes))
+		for _, ch := range diff.Changes {
+			log.Printf("[INFO] %s %s", p.logName(), ch)
 		}
 	}
-	return nil
 }
 
 // MakeCalendar собирает календарь на один год из источников Src.
@@ -117,46 +215,206 @@ func (p *Processor) UpdateCalendar(y int) error {
 // Если источник вернет ошибку, он будет пропущен. Если все источники вернут ошибку Src будет пуст, то
 // возвращается пустой store.Months (len=0).
 func (p *Processor) MakeCalendar(y int) store.Months {
-	cal := make(store.Months, 12)
+	cal, _, _, _ := p.makeCalendar(y, nil)
+	return cal
+}
+
+// keptYear — сохраненный год, дни которого заменяют данные недоступного внешнего источника.
+type keptYear struct {
+	months store.Months
+	prov   store.Provenance // nil, если источники дней неизвестны.
+}
+
+// keptYear возвращает сохраненный год prev, если в нем могут быть данные внешних источников.
+// Для года, сгенерированного по правилам (store.StatusGenerated), возвращается nil: терять в нем нечего.
+func (p *Processor) keptYear(y int, prev store.Months) *keptYear {
+	ms, ok := p.Store.(MetaStore)
+	if !ok {
+		return &keptYear{months: prev}
+	}
+	if meta, found := ms.FindYearMeta(y); found && meta.Status == store.StatusGenerated {
+		return nil
+	}
+	prov, _ := ms.FindProvenance(y)
+	return &keptYear{months: prev, prov: prov}
+}
+
+// makeCalendar аналогичен MakeCalendar, но возвращает также источники полей каждого дня, названия источников,
+// данные которых вошли в календарь, и ошибки проверки данных внешних источников (External), если задан Validator.
+// Расхождение источников в режиме ConsensusReject считается ошибкой проверки, даже если Validator не задан.
+// Если kept не nil, вместо данных недоступного внешнего источника берутся сохраненные дни, см. UpdateCalendar.
+func (p *Processor) makeCalendar(y int, kept *keptYear) (cal store.Months, prov store.Provenance, sources []string, problems []string) {
+	cal = make(store.Months, 12)
+	prov = make(store.Provenance, 12)
+	sources = make([]string, 0, len(p.Src))
+	var failed []failedSource
 
 	for i, src := range p.Src {
-		log.Printf("[DEBUG] calendar/proc make calendar y=%d, src=%d (%s)", y, i, reflect.TypeOf(src))
+		log.Printf("[DEBUG] %s make calendar y=%d, src=%d (%s)", p.logName(), y, i, reflect.TypeOf(src))
+		ext, isExt := src.(External)
+		isExt = isExt && ext.External()
+
 		months, err := src.GetYear(y)
+		if err == nil && len(months) == 0 && isExt {
+			// Например, страница года еще не опубликована или изменилась верстка сайта.
+			err = errors.New("no data")
+		}
 		if err != nil {
-			log.Printf("[WARN] calendar/proc skipping source %d (%T), error: %+v", i, src, err)
+			var cErr *ConsensusError
+			switch {
+			case errors.As(err, &cErr):
+				problems = append(problems, fmt.Sprintf("source %s: %v", sourceName(src), err))
+			case isExt && kept != nil:
+				log.Printf("[WARN] %s source %d (%T) failed, using stored days of %d instead, error: %+v",
+					p.logName(), i, src, y, err)
+				cal = merge(cal, prov, kept.months, sourceName(src))
+				failed = append(failed, failedSource{i, err})
+				continue
+			}
+			log.Printf("[WARN] %s skipping source %d (%T), error: %+v", p.logName(), i, src, err)
 			continue
 		}
 
-		cal = merge(cal, months)
+		if isExt && p.Validator != nil {
+			for _, pr := range p.Validator.checkSource(y, months) {
+				problems = append(problems, fmt.Sprintf("source %s: %s", sourceName(src), pr))
+			}
+		}
+
+		cal = merge(cal, prov, months, sourceName(src))
+		sources = append(sources, sourceName(src))
 	}
 
-	return cal
+	for _, f := range failed {
+		name := sourceName(p.Src[f.index])
+		if pr := restoreKept(y, prov, kept, name, p.Src[f.index+1:]); pr != "" {
+			problems = append(problems, fmt.Sprintf("source %s failed (%v), %s", name, f.err, pr))
+		}
+	}
+
+	return cal, prov, sources, problems
 }
 
-func merge(m1 store.Months, m2 store.Months) store.Months {
+// failedSource — внешний источник, вместо данных которого взяты сохраненные дни.
+type failedSource struct {
+	index int // Индекс в ProcOpts.Src.
+	err   error
+}
+
+// restoreKept заменяет в prov название недоступного источника name у полей сохраненных дней их источниками
+// из kept.prov. Возвращает описание проблемы, если сохраненные дни использовать нельзя: источники дней неизвестны
+// или один из источников after (следующих за недоступным) больше не заполняет поле, которое он определил
+// в сохраненном году. Без данных недоступного источника прежнее значение такого поля не восстановить.
+func restoreKept(y int, prov store.Provenance, kept *keptYear, name string, after []Source) string {
+	if kept.prov == nil {
+		return "sources of the stored days are unknown"
+	}
+
+	afterNames := make(map[string]bool, len(after))
+	for _, src := range after {
+		afterNames[sourceName(src)] = true
+	}
+
+	var lost []string
+	for date := store.NewDate(y, time.January, 1); date.Year() == y; date = date.AddDate(0, 0, 1) {
+		keptSrc, ok := kept.prov[date.Month()][date.Day()]
+		if !ok || prov[date.Month()] == nil {
+			continue
+		}
+
+		cur := prov[date.Month()][date.Day()]
+		curFields, keptFields := sourceFields(&cur), sourceFields(&keptSrc)
+		removed := false
+		for i, field := range curFields {
+			switch keptName := *keptFields[i]; {
+			case afterNames[keptName] && *field != keptName:
+				removed = true
+			case *field == name && keptName != "":
+				*field = keptName
+			}
+		}
+		prov[date.Month()][date.Day()] = cur
+
+		if removed {
+			lost = append(lost, date.Format(store.DateLayout))
+		}
+	}
+
+	if len(lost) > 0 {
+		return fmt.Sprintf("data removed from the other sources cannot be replaced without it: %d day(s) (%s)",
+			len(lost), shortList(lost, 3))
+	}
+	return ""
+}
+
+// sourceFields возвращает указатели на все поля ds.
+func sourceFields(ds *store.DaySource) []*string {
+	return []*string{&ds.WeekDay, &ds.Working, &ds.Type, &ds.Desc}
+}
+
+func sourceName(src Source) string {
+	if n, ok := src.(Named); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", src)
+}
+
+// merge накладывает данные источника src (m2) на календарь m1 и записывает в prov, какой источник определил
+// каждое поле дня. Working определяется последним источником, который вернул день; остальные поля — последним
+// источником, который их заполнил. Provisional, как и Working, определяется последним источником.
+// Если источник изменил Working, но не задал тип, а прежний тип ему противоречит (рабочий выходной), тип
+// заменяется на обычный рабочий день или выходной, см. typeFor.
+func merge(m1 store.Months, prov store.Provenance, m2 store.Months, src string) store.Months {
 	res := m1.Copy()
 	for mon, days := range m2 {
 		_, monExists := res[mon]
 		if !monExists {
 			res[mon] = make(store.Days, len(days))
 		}
+		if prov[mon] == nil {
+			prov[mon] = make(map[int]store.DaySource, len(days))
+		}
 
 		for dayNum, day := range days {
 			merged := res[mon][dayNum]
+			mergedSrc := prov[mon][dayNum]
+
 			merged.Working = day.Working
+			merged.Provisional = day.Provisional
+			mergedSrc.Working = src
 
 			if day.WeekDay != "" {
 				merged.WeekDay = day.WeekDay
+				mergedSrc.WeekDay = src
 			}
 			if day.Type != "" {
 				merged.Type = day.Type
+				mergedSrc.Type = src
+			} else if t, ok := typeFor(merged.Working, merged.Type); ok {
+				merged.Type = t
+				mergedSrc.Type = src
 			}
 			if day.Desc != "" {
 				merged.Desc = day.Desc
+				mergedSrc.Desc = src
 			}
 
 			res[mon][dayNum] = merged
+			prov[mon][dayNum] = mergedSrc
 		}
 	}
 	return res
 }
+
+// typeFor возвращает тип дня, если тип t не соответствует working: выходной стал рабочим днем или рабочий день
+// стал нерабочим. Праздники и предпраздничные дни не меняются.
+func typeFor(working bool, t store.DayType) (store.DayType, bool) {
+	switch {
+	case working && t == store.Weekend:
+		return store.Normal, true
+	case !working && t == store.Normal:
+		return store.Weekend, true
+	default:
+		return t, false
+	}
+}
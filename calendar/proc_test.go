@@ -27,6 +27,16 @@ func (s StoreMock) PutYear(y int, m store.Months) error {
 	return nil
 }
 
+func (s StoreMock) FindYear(y int) (store.Months, bool) {
+	m, ok := s[y]
+	return m, ok
+}
+
+func (s StoreMock) FindMonth(y int, mon time.Month) (store.Days, bool) {
+	d, ok := s[y][mon]
+	return d, ok
+}
+
 func TestProcessor_MakeCalendar(t *testing.T) {
 	src1 := SrcMock{2022: {
 		time.February: {
@@ -49,7 +59,7 @@ func TestProcessor_MakeCalendar(t *testing.T) {
 		Src:   []Source{src1, src2},
 		Store: tmpStore,
 	})
-	err := p.UpdateCalendar(2022)
+	_, err := p.UpdateCalendar(2022)
 	assert.NoError(t, err)
 
 	expStore := StoreMock{2022: {
@@ -63,16 +73,207 @@ func TestProcessor_MakeCalendar(t *testing.T) {
 	assert.Equal(t, expStore, tmpStore)
 }
 
+func TestProcessor_MakeCalendar_typeWithoutSource(t *testing.T) {
+	src1 := SrcMock{2022: {
+		time.March: {
+			4: {WeekDay: store.Friday, Working: true, Type: store.PreHoliday},
+			5: {WeekDay: store.Saturday, Working: false, Type: store.Weekend},
+			6: {WeekDay: store.Sunday, Working: false, Type: store.Weekend},
+			7: {WeekDay: store.Monday, Working: true, Type: store.Normal},
+			8: {WeekDay: store.Tuesday, Working: false, Type: store.Holiday},
+		},
+	}}
+	// Источник меняет только Working (как перенос по постановлению): противоречащий тип заменяется,
+	// праздники и предпраздничные дни остаются.
+	src2 := SrcMock{2022: {
+		time.March: {
+			4: {Working: true},
+			5: {Working: true},
+			7: {Working: false},
+			8: {Working: false},
+		},
+	}}
+
+	p, _ := makeProcessor(ProcOpts{Src: []Source{src1, src2}})
+	cal := p.MakeCalendar(2022)
+
+	assert.Equal(t, store.PreHoliday, cal[time.March][4].Type)
+	assert.Equal(t, store.Normal, cal[time.March][5].Type)
+	assert.Equal(t, store.Weekend, cal[time.March][6].Type)
+	assert.Equal(t, store.Weekend, cal[time.March][7].Type)
+	assert.Equal(t, store.Holiday, cal[time.March][8].Type)
+}
+
+type RevisionStoreMock map[int]store.Revision
+
+func (s RevisionStoreMock) FindYear(y int) (store.Months, bool) {
+	rev, ok := s[y]
+	return rev.Months, ok
+}
+
+func (s RevisionStoreMock) PutYear(y int, m store.Months) error {
+	panic("PutRevision must be used")
+}
+
+func (s RevisionStoreMock) PutRevision(y int, rev store.Revision) error {
+	s[y] = rev
+	return nil
+}
+
+func (s RevisionStoreMock) FindYearMeta(y int) (*store.YearMeta, bool) {
+	rev, ok := s[y]
+	if !ok {
+		return nil, false
+	}
+	return &store.YearMeta{Modified: rev.Time, Hash: rev.Hash, Status: rev.Status}, true
+}
+
+func (s RevisionStoreMock) FindProvenance(y int) (store.Provenance, bool) {
+	rev, ok := s[y]
+	return rev.Provenance, ok && rev.Provenance != nil
+}
+
+type namedSrc struct {
+	SrcMock
+	name string
+}
+
+func (s namedSrc) Name() string {
+	return s.name
+}
+
+func TestProcessor_UpdateCalendar_sources(t *testing.T) {
+	src1 := namedSrc{SrcMock{2022: {time.January: {1: {Working: false, Type: store.Holiday}}}}, "first"}
+	src2 := SrcMock{2023: {}} // Ошибка для 2022, в список не попадет.
+	src3 := SrcMock{2022: {time.January: {2: {Working: false, Type: store.Holiday}}}}
+
+	tmpStore := RevisionStoreMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Src:   []Source{src1, src2, src3},
+		Store: tmpStore,
+	})
+	_, err := p.UpdateCalendar(2022)
+	assert.NoError(t, err)
+
+	rev := tmpStore[2022]
+	assert.Equal(t, []string{"first", "calendar.SrcMock"}, rev.Sources)
+	assert.Len(t, rev.Months[time.January], 2)
+	assert.Equal(t, rev.Months.Hash(), rev.Hash)
+}
+
+func TestProcessor_UpdateCalendar_provenance(t *testing.T) {
+	generic := namedSrc{SrcMock{2022: {time.February: {
+		22: {WeekDay: store.Tuesday, Working: true, Type: store.Normal},
+		23: {WeekDay: store.Wednesday, Working: true, Type: store.Normal},
+	}}}, "generic"}
+	parser := namedSrc{SrcMock{2022: {time.February: {
+		22: {Working: true, Type: store.PreHoliday},
+		23: {Working: false, Type: store.Holiday},
+	}}}, "consultant"}
+	override := namedSrc{SrcMock{2022: {time.February: {
+		23: {Working: false, Desc: "День защитника Отечества"},
+	}}}, "override:cal.yml"}
+
+	tmpStore := RevisionStoreMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Src:   []Source{generic, parser, override},
+		Store: tmpStore,
+	})
+	_, err := p.UpdateCalendar(2022)
+	assert.NoError(t, err)
+
+	expProv := store.Provenance{time.February: {
+		22: {WeekDay: "generic", Working: "consultant", Type: "consultant"},
+		23: {WeekDay: "generic", Working: "override:cal.yml", Type: "consultant", Desc: "override:cal.yml"},
+	}}
+	assert.Equal(t, expProv, tmpStore[2022].Provenance)
+}
+
+func TestProcessor_UpdateCalendar_diff(t *testing.T) {
+	src := SrcMock{2022: {
+		time.May: {
+			1: {WeekDay: store.Sunday, Working: false, Type: store.Holiday},
+			2: {WeekDay: store.Monday, Working: true, Type: store.Normal},
+		},
+	}}
+	tmpStore := StoreMock{}
+
+	notifier := &notifierMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Name:      "test",
+		Src:       []Source{src},
+		Store:     tmpStore,
+		Notifiers: []Notifier{notifier},
+	})
+
+	diff, err := p.UpdateCalendar(2022)
+	assert.NoError(t, err)
+	assert.True(t, diff.Created)
+	assert.Equal(t, "test", diff.Calendar)
+	assert.Len(t, diff.Changes, 2)
+
+	diff, err = p.UpdateCalendar(2022)
+	assert.NoError(t, err)
+	assert.False(t, diff.Created)
+	assert.False(t, diff.Changed())
+	assert.Equal(t, []DayChange{}, diff.Changes)
+	assert.Len(t, notifier.diffs, 1) // Без изменений уведомления нет.
+
+	src[2022] = store.Months{
+		time.May: {
+			1: {WeekDay: store.Sunday, Working: false, Type: store.Holiday},
+			2: {WeekDay: store.Monday, Working: false, Type: store.Holiday, Desc: "Перенос"},
+			3: {WeekDay: store.Tuesday, Working: true, Type: store.Normal},
+		},
+	}
+	diff, err = p.UpdateCalendar(2022)
+	assert.NoError(t, err)
+	expChanges := []DayChange{
+		{
+			Date: "2022-05-02",
+			Kind: DayChanged,
+			Old:  &store.Day{WeekDay: store.Monday, Working: true, Type: store.Normal},
+			New:  &store.Day{WeekDay: store.Monday, Working: false, Type: store.Holiday, Desc: "Перенос"},
+		},
+		{
+			Date: "2022-05-03",
+			Kind: DayAdded,
+			New:  &store.Day{WeekDay: store.Tuesday, Working: true, Type: store.Normal},
+		},
+	}
+	assert.Equal(t, expChanges, diff.Changes)
+	if assert.Len(t, notifier.diffs, 2) {
+		assert.Equal(t, diff, notifier.diffs[1])
+	}
+	assert.Equal(t, `2022-05-02 changed: working normal -> non-working holiday "Перенос"`, diff.Changes[0].String())
+}
+
+type notifierMock struct {
+	diffs []Diff
+}
+
+func (n *notifierMock) Notify(diff Diff) {
+	n.diffs = append(n.diffs, diff)
+}
+
+func TestDiffYears_removed(t *testing.T) {
+	old := store.Months{time.May: {1: {Working: false, Type: store.Holiday}}}
+	changes := DiffYears(2022, old, store.Months{})
+	assert.Equal(t, []DayChange{{Date: "2022-05-01", Kind: DayRemoved, Old: &store.Day{Working: false, Type: store.Holiday}}}, changes)
+}
+
 func TestProcessor_DoUpdates(t *testing.T) {
+	// Обновляются только текущий и следующий год.
+	y := time.Now().Year()
 	src := SrcMock{
-		2022: {
+		y: {
 			time.January: {
-				1: {WeekDay: store.Saturday, Working: false, Type: store.Holiday},
+				1: {Working: false, Type: store.Holiday},
 			},
 		},
-		2023: {
+		y + 1: {
 			time.January: {
-				1: {WeekDay: store.Sunday, Working: false, Type: store.Holiday},
+				1: {Working: false, Type: store.Holiday},
 			},
 		},
 	}
@@ -95,7 +296,30 @@ func TestProcessor_DoUpdates(t *testing.T) {
 func makeProcessor(opts ProcOpts) (p *Processor, stop func()) {
 	p = NewProcessor(opts)
 	return p, func() {
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint:govet // В тестах можно без cancel.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
 		_ = p.Shutdown(ctx)
 	}
 }
+
+func TestProcessor_UpdateCalendar_provisional(t *testing.T) {
+	generic := SrcMock{2022: {time.March: {
+		7: {WeekDay: store.Monday, Working: true, Type: store.Normal, Provisional: true},
+		8: {WeekDay: store.Tuesday, Working: false, Type: store.Holiday, Provisional: true},
+	}}}
+	decree := SrcMock{2022: {time.March: {
+		7: {Working: false, Type: store.Weekend},
+	}}}
+
+	tmpStore := StoreMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Src:   []Source{generic, decree},
+		Store: tmpStore,
+	})
+	_, err := p.UpdateCalendar(2022)
+	assert.NoError(t, err)
+
+	// День, который вернул следующий источник, подтвержден.
+	assert.False(t, tmpStore[2022][time.March][7].Provisional)
+	assert.True(t, tmpStore[2022][time.March][8].Provisional)
+}
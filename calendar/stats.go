@@ -0,0 +1,62 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// Stats — количество дней каждого типа в диапазоне дат.
+type Stats struct {
+	CalendarDays int `json:"calendarDays"`
+	Working      int `json:"working"`    // Все рабочие дни, включая предпраздничные и нерабочие (noWork).
+	NonWorking   int `json:"nonWorking"` // Выходные и праздничные дни.
+
+	Normal     int `json:"normal"`
+	Weekend    int `json:"weekend"`
+	Holiday    int `json:"holiday"`
+	PreHoliday int `json:"preHoliday"`
+	NoWork     int `json:"noWork"`
+
+	// Количество рабочих часов при 40-часовой рабочей неделе (предпраздничные дни короче на 1 час).
+	WorkHours int `json:"workHours"`
+}
+
+// RangeStats считает статистику по дням в диапазоне [from, to], обе даты включаются.
+func RangeStats(f Finder, from, to time.Time) (Stats, error) {
+	days, err := Range(f, from, to)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var st Stats
+	for _, d := range days {
+		st.Add(d.Day)
+	}
+	return st, nil
+}
+
+// Add учитывает в статистике еще один день.
+func (st *Stats) Add(d store.Day) {
+	st.CalendarDays++
+	if d.Working {
+		st.Working++
+		st.WorkHours += 8
+	} else {
+		st.NonWorking++
+	}
+
+	switch d.Type {
+	case store.Normal:
+		st.Normal++
+	case store.Weekend:
+		st.Weekend++
+	case store.Holiday:
+		st.Holiday++
+	case store.PreHoliday:
+		st.PreHoliday++
+		st.WorkHours--
+	case store.NonWorking:
+		st.NoWork++
+	}
+}
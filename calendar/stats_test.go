@@ -0,0 +1,36 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeStats(t *testing.T) {
+	y2022 := makeYear(2022, 1, 2, 3, 4, 5, 6, 7)
+	y2022[time.January][28] = store.Day{WeekDay: store.Friday, Working: true, Type: store.PreHoliday}
+	y2022[time.January][27] = store.Day{WeekDay: store.Thursday, Working: true, Type: store.NonWorking}
+	f := StoreMock{2022: y2022}
+
+	st, err := RangeStats(f, store.NewDate(2022, time.January, 1), store.NewDate(2022, time.January, 31))
+	assert.NoError(t, err)
+
+	expStats := Stats{
+		CalendarDays: 31,
+		Working:      16,
+		NonWorking:   15,
+		Normal:       14,
+		Weekend:      8,
+		Holiday:      7,
+		PreHoliday:   1,
+		NoWork:       1,
+		WorkHours:    16*8 - 1,
+	}
+	assert.Equal(t, expStats, st)
+
+	_, err = RangeStats(f, store.NewDate(2022, time.December, 1), store.NewDate(2023, time.January, 31))
+	var missingErr *MissingYearsError
+	assert.ErrorAs(t, err, &missingErr)
+}
@@ -0,0 +1,103 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// External отмечает источники, которые загружают календарь из внешней системы (парсеры сайтов).
+// Если задан ProcOpts.Validator, ошибка или подозрительный результат такого источника
+// не позволяет перезаписать календарь в хранилище.
+type External interface {
+	External() bool
+}
+
+// Validator проверяет календарь перед сохранением. Нулевое значение ограничения отключает проверку.
+type Validator struct {
+	MinHolidays    int // Минимальное количество праздничных дней в году у внешнего источника.
+	MaxHolidays    int // Максимальное количество праздничных дней в году у внешнего источника.
+	MaxChangedDays int // Сколько дней может стать рабочими или нерабочими по сравнению с сохраненным календарем.
+}
+
+// ValidationError возвращается из UpdateCalendar, если календарь не прошел проверку и не был сохранен.
+type ValidationError struct {
+	Calendar string
+	Year     int
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	name := e.Calendar
+	if name == "" {
+		name = "main"
+	}
+	return fmt.Sprintf("calendar %s, year %d was not stored, validation failed: %s",
+		name, e.Year, strings.Join(e.Problems, "; "))
+}
+
+// checkSource проверяет данные внешнего источника: все дни года на месте, 1 января — праздник,
+// количество праздников в допустимых пределах.
+func (v *Validator) checkSource(y int, months store.Months) []string {
+	var problems []string
+
+	var missing []string
+	holidays := 0
+	for date := store.NewDate(y, time.January, 1); date.Year() == y; date = date.AddDate(0, 0, 1) {
+		day, ok := months[date.Month()][date.Day()]
+		if !ok {
+			missing = append(missing, date.Format(store.DateLayout))
+			continue
+		}
+		if day.Type == store.Holiday {
+			holidays++
+		}
+	}
+
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("%d day(s) missing (%s)", len(missing), shortList(missing, 3)))
+	}
+
+	if jan1, ok := months[time.January][1]; ok && (jan1.Working || jan1.Type != store.Holiday) {
+		problems = append(problems, "January 1 is not a holiday")
+	}
+
+	if v.MinHolidays > 0 && holidays < v.MinHolidays {
+		problems = append(problems, fmt.Sprintf("too few holidays: %d, expected at least %d", holidays, v.MinHolidays))
+	}
+	if v.MaxHolidays > 0 && holidays > v.MaxHolidays {
+		problems = append(problems, fmt.Sprintf("too many holidays: %d, expected at most %d", holidays, v.MaxHolidays))
+	}
+
+	return problems
+}
+
+// checkChanges проверяет, что новый календарь не слишком отличается от сохраненного.
+// Учитываются только дни, которые стали рабочими или нерабочими, а также пропавшие дни.
+func (v *Validator) checkChanges(changes []DayChange) []string {
+	if v.MaxChangedDays <= 0 {
+		return nil
+	}
+
+	changed := 0
+	for _, ch := range changes {
+		if ch.Kind != DayChanged || ch.Old.Working != ch.New.Working {
+			changed++
+		}
+	}
+
+	if changed > v.MaxChangedDays {
+		return []string{fmt.Sprintf("too many days changed compared to the stored calendar: %d, expected at most %d",
+			changed, v.MaxChangedDays)}
+	}
+	return nil
+}
+
+func shortList(items []string, max int) string {
+	if len(items) <= max {
+		return strings.Join(items, ", ")
+	}
+	return strings.Join(items[:max], ", ") + ", ..."
+}
@@ -0,0 +1,271 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type externalSrc struct {
+	SrcMock
+}
+
+func (externalSrc) Name() string {
+	return "parser"
+}
+
+func (externalSrc) External() bool {
+	return true
+}
+
+// parsedYear — полный календарь на год, в котором праздники — 1-8 января и даты holidays.
+func parsedYear(y int, holidays ...time.Time) store.Months {
+	months := make(store.Months, 12)
+	for date := store.NewDate(y, time.January, 1); date.Year() == y; date = date.AddDate(0, 0, 1) {
+		if months[date.Month()] == nil {
+			months[date.Month()] = make(store.Days)
+		}
+
+		wd, _ := store.NewWeekDay(date.Weekday())
+		day := store.Day{WeekDay: wd, Working: true, Type: store.Normal}
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			day.Working, day.Type = false, store.Weekend
+		}
+		if date.Month() == time.January && date.Day() <= 8 {
+			day.Working, day.Type = false, store.Holiday
+		}
+		for _, h := range holidays {
+			if h.Equal(date) {
+				day.Working, day.Type = false, store.Holiday
+			}
+		}
+		months[date.Month()][date.Day()] = day
+	}
+	return months
+}
+
+func TestProcessor_UpdateCalendar_validation(t *testing.T) {
+	good := parsedYear(2022, store.NewDate(2022, time.February, 23), store.NewDate(2022, time.March, 8))
+
+	noJan1 := parsedYear(2022, store.NewDate(2022, time.February, 23), store.NewDate(2022, time.March, 8),
+		store.NewDate(2022, time.May, 9))
+	noJan1[time.January][1] = store.Day{WeekDay: store.Saturday, Working: false, Type: store.Weekend}
+
+	partial := parsedYear(2022, store.NewDate(2022, time.February, 23), store.NewDate(2022, time.March, 8))
+	delete(partial, time.December)
+	delete(partial[time.November], 30)
+
+	tooMany := parsedYear(2022)
+	for d := 1; d <= 31; d++ {
+		tooMany[time.August][d] = store.Day{Working: false, Type: store.Holiday}
+	}
+
+	validator := &Validator{MinHolidays: 10, MaxHolidays: 40, MaxChangedDays: 5}
+
+	tbl := []struct {
+		name     string
+		data     store.Months
+		stored   store.Months
+		problems []string
+	}{
+		{name: "ok", data: good},
+		{
+			name:     "missing days",
+			data:     partial,
+			problems: []string{"source parser: 32 day(s) missing (2022-11-30, 2022-12-01, 2022-12-02, ...)"},
+		},
+		{
+			name:     "January 1",
+			data:     noJan1,
+			problems: []string{"source parser: January 1 is not a holiday"},
+		},
+		{
+			name:     "too few holidays",
+			data:     parsedYear(2022),
+			problems: []string{"source parser: too few holidays: 8, expected at least 10"},
+		},
+		{
+			name:     "too many changes",
+			data:     tooMany,
+			stored:   good,
+			problems: []string{"too many days changed compared to the stored calendar: 25, expected at most 5"},
+		},
+	}
+
+	for _, tc := range tbl {
+		t.Run(tc.name, func(t *testing.T) {
+			st := StoreMock{}
+			if tc.stored != nil {
+				st[2022] = tc.stored
+			}
+
+			p, _ := makeProcessor(ProcOpts{
+				Name:      "tatarstan",
+				Src:       []Source{externalSrc{SrcMock{2022: tc.data}}},
+				Store:     st,
+				Validator: validator,
+			})
+			_, err := p.UpdateCalendar(2022)
+
+			if len(tc.problems) == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, tc.data, st[2022])
+				return
+			}
+
+			var vErr *ValidationError
+			require.ErrorAs(t, err, &vErr)
+			assert.Equal(t, "tatarstan", vErr.Calendar)
+			assert.Equal(t, 2022, vErr.Year)
+			assert.Equal(t, tc.problems, vErr.Problems)
+			assert.Equal(t, tc.stored, st[2022], "store must not be changed")
+		})
+	}
+}
+
+func TestProcessor_UpdateCalendar_validationFailedSource(t *testing.T) {
+	stored := parsedYear(2022, store.NewDate(2022, time.February, 23), store.NewDate(2022, time.March, 8))
+	generic := parsedYear(2022)
+
+	// Парсер не вернул данных (например, изменилась верстка сайта), а хранилище не знает источников сохраненных
+	// дней: календарь, собранный из остальных источников, не должен заменить сохраненный.
+	st := StoreMock{2022: stored}
+	p, _ := makeProcessor(ProcOpts{
+		Src:       []Source{SrcMock{2022: generic}, externalSrc{SrcMock{}}},
+		Store:     st,
+		Validator: &Validator{MaxChangedDays: 40},
+	})
+	_, err := p.UpdateCalendar(2022)
+
+	var vErr *ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []string{"source parser failed (no such year: 2022), sources of the stored days are unknown"},
+		vErr.Problems)
+	assert.Equal(t, stored, st[2022], "store must not be changed")
+
+	// Год еще не сохранен — сохраняется календарь из остальных источников.
+	_, err = p.UpdateCalendar(2023)
+	assert.NoError(t, err)
+
+	// Без Validator ошибка источника только пропускает его.
+	p.Validator = nil
+	_, err = p.UpdateCalendar(2022)
+	require.NoError(t, err)
+	assert.Equal(t, generic, st[2022])
+}
+
+func TestProcessor_UpdateCalendar_emptyExternalSource(t *testing.T) {
+	// Страница года еще не опубликована: парсер возвращает пустой календарь без ошибки.
+	st := RevisionStoreMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Src:       []Source{namedSrc{SrcMock{2027: provisional(parsedYear(2027))}, "generic"}, externalSrc{SrcMock{2027: {}}}},
+		Store:     st,
+		Validator: &Validator{MinHolidays: 10, MaxChangedDays: 5},
+	})
+	_, err := p.UpdateCalendar(2027)
+	require.NoError(t, err)
+	assert.Equal(t, store.StatusGenerated, st[2027].Status)
+	assert.Equal(t, []string{"generic"}, st[2027].Sources)
+}
+
+func TestProcessor_UpdateCalendar_failedSourceKeepsStored(t *testing.T) {
+	generic := namedSrc{SrcMock{2022: provisional(parsedYear(2022))}, "generic"}
+	parser := externalSrc{SrcMock{2022: parsedYear(2022, store.NewDate(2022, time.February, 23))}}
+	mar7 := store.Day{WeekDay: store.Monday, Working: false, Type: store.Holiday, Desc: "Перенос"}
+	override := namedSrc{SrcMock{2022: {}}, "override"}
+
+	st := RevisionStoreMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Src:       []Source{generic, &parser, &override},
+		Store:     st,
+		Validator: &Validator{MaxChangedDays: 5},
+	})
+	_, err := p.UpdateCalendar(2022)
+	require.NoError(t, err)
+	require.Equal(t, store.StatusConfirmed, st[2022].Status)
+
+	// Парсер недоступен, но новое переопределение попадает в календарь поверх сохраненных дней парсера.
+	parser.SrcMock = SrcMock{}
+	override.SrcMock = SrcMock{2022: {time.March: {7: mar7}}}
+	diff, err := p.UpdateCalendar(2022)
+	require.NoError(t, err)
+	assert.Len(t, diff.Changes, 1)
+	assert.Equal(t, mar7, st[2022].Months[time.March][7])
+	assert.Equal(t, store.Holiday, st[2022].Months[time.February][23].Type, "stored parser days must be kept")
+	assert.Equal(t, store.StatusConfirmed, st[2022].Status)
+	assert.Equal(t, store.DaySource{WeekDay: "parser", Working: "parser", Type: "parser"},
+		st[2022].Provenance[time.February][23])
+	assert.Equal(t, "override", st[2022].Provenance[time.March][7].Working)
+
+	// Удаленное переопределение нельзя убрать без данных парсера: прежнее значение дня неизвестно.
+	override.SrcMock = SrcMock{2022: {}}
+	_, err = p.UpdateCalendar(2022)
+	var vErr *ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []string{"source parser failed (no such year: 2022), data removed from the other sources " +
+		"cannot be replaced without it: 1 day(s) (2022-03-07)"}, vErr.Problems)
+	assert.Equal(t, mar7, st[2022].Months[time.March][7])
+}
+
+func TestProcessor_UpdateCalendar_failedSourceGenerated(t *testing.T) {
+	// В сгенерированном году нет данных парсера: пока он недоступен, год собирается из остальных источников.
+	generic := namedSrc{SrcMock{2027: provisional(parsedYear(2027))}, "generic"}
+	mar8 := store.Day{WeekDay: store.Monday, Working: false, Type: store.Holiday}
+	decree := namedSrc{SrcMock{2027: {}}, "decree"}
+
+	st := RevisionStoreMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Src:       []Source{generic, externalSrc{SrcMock{}}, &decree},
+		Store:     st,
+		Validator: &Validator{MaxChangedDays: 5},
+	})
+	_, err := p.UpdateCalendar(2027)
+	require.NoError(t, err)
+	require.Equal(t, store.StatusGenerated, st[2027].Status)
+
+	decree.SrcMock = SrcMock{2027: {time.March: {8: mar8}}}
+	_, err = p.UpdateCalendar(2027)
+	require.NoError(t, err)
+	assert.Equal(t, mar8, st[2027].Months[time.March][8])
+	assert.Equal(t, store.StatusPartial, st[2027].Status)
+}
+
+// provisional отмечает все дни months как предварительные, как это делает source.Generic.
+func provisional(months store.Months) store.Months {
+	for _, days := range months {
+		for dayNum, day := range days {
+			day.Provisional = true
+			days[dayNum] = day
+		}
+	}
+	return months
+}
+
+func TestValidator_checkSource_maxHolidays(t *testing.T) {
+	months := parsedYear(2022)
+	for d := 1; d <= 31; d++ {
+		months[time.March][d] = store.Day{Working: false, Type: store.Holiday}
+	}
+
+	v := &Validator{MaxHolidays: 30}
+	assert.Equal(t, []string{"too many holidays: 39, expected at most 30"}, v.checkSource(2022, months))
+
+	v = &Validator{}
+	assert.Empty(t, v.checkSource(2022, months))
+}
+
+func TestProcessor_UpdateCalendar_validationInternal(t *testing.T) {
+	// Данные источников, которые не являются внешними (например, встроенные правила страны), не проверяются.
+	st := StoreMock{}
+	p, _ := makeProcessor(ProcOpts{
+		Src:       []Source{SrcMock{2022: parsedYear(2022)}},
+		Store:     st,
+		Validator: &Validator{MinHolidays: 10},
+	})
+	_, err := p.UpdateCalendar(2022)
+	require.NoError(t, err)
+	assert.Contains(t, st, 2022)
+}
@@ -0,0 +1,185 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// ErrDateNotFound возвращается, если год синхронизирован, но в нем нет нужной даты.
+var ErrDateNotFound = errors.New("date not found")
+
+// Finder — хранилище, по данным которого выполняются вычисления над датами.
+type Finder interface {
+	FindMonth(y int, mon time.Month) (store.Days, bool)
+}
+
+// MissingYearsError возвращается, если для вычисления нужен год, который еще не синхронизирован.
+// Отсутствующие дни нельзя считать ни рабочими, ни выходными, поэтому вычисление прерывается.
+type MissingYearsError struct {
+	Years []int
+}
+
+func (e *MissingYearsError) Error() string {
+	years := make([]string, len(e.Years))
+	for i, y := range e.Years {
+		years[i] = strconv.Itoa(y)
+	}
+	return fmt.Sprintf("calendar is not synced for year(s) %s", strings.Join(years, ", "))
+}
+
+// AddWorkDays прибавляет к date n рабочих дней (n может быть отрицательным) и возвращает получившийся день.
+// Сама дата date не учитывается: при n=1 вернется ближайший рабочий день после date, при n=0 — сама date,
+// даже если она нерабочая.
+func AddWorkDays(f Finder, date time.Time, n int) (store.DateDay, error) {
+	c := newMonthCache(f)
+	date = truncDate(date)
+
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+
+	for n > 0 {
+		date = date.AddDate(0, 0, step)
+
+		day, err := c.day(date)
+		if err != nil {
+			return store.DateDay{}, err
+		}
+		if day.Working {
+			n--
+		}
+	}
+
+	day, err := c.day(date)
+	if err != nil {
+		return store.DateDay{}, err
+	}
+	return store.DateDay{Date: date, Day: day}, nil
+}
+
+// NextWorkDay возвращает n-й по счету рабочий день, начиная с date включительно (n >= 1).
+// При n=1 вернется сама date, если она рабочая, иначе — ближайший рабочий день после нее.
+func NextWorkDay(f Finder, date time.Time, n int) (store.DateDay, error) {
+	return AddWorkDays(f, truncDate(date).AddDate(0, 0, -1), n)
+}
+
+// PrevWorkDay аналогичен NextWorkDay, но ищет рабочие дни в обратном направлении: date или ранее.
+func PrevWorkDay(f Finder, date time.Time, n int) (store.DateDay, error) {
+	return AddWorkDays(f, truncDate(date).AddDate(0, 0, 1), -n)
+}
+
+// FindDays ищет дни по списку дат, которые могут относиться к разным месяцам и годам и идти в любом порядке.
+// Каждый месяц читается из хранилища только один раз. Результат соответствует dates по индексу,
+// для дат, которых нет в хранилище, в результате будет nil.
+func FindDays(f Finder, dates []time.Time) []*store.Day {
+	c := newMonthCache(f)
+
+	res := make([]*store.Day, len(dates))
+	for i, date := range dates {
+		day, err := c.day(truncDate(date))
+		if err != nil {
+			continue
+		}
+		res[i] = &day
+	}
+	return res
+}
+
+// Range возвращает все дни в диапазоне [from, to] в порядке возрастания даты.
+// Если какой-либо год из диапазона не синхронизирован, возвращается *MissingYearsError со списком всех таких лет.
+func Range(f Finder, from, to time.Time) ([]store.DateDay, error) {
+	from, to = truncDate(from), truncDate(to)
+	if to.Before(from) {
+		return nil, nil
+	}
+
+	c := newMonthCache(f)
+	if err := c.load(from, to); err != nil {
+		return nil, err
+	}
+
+	days := make([]store.DateDay, 0, int(to.Sub(from).Hours()/24)+1)
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		day, err := c.day(date)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, store.DateDay{Date: date, Day: day})
+	}
+	return days, nil
+}
+
+// monthCache запоминает месяцы, прочитанные из Finder, на время одного вычисления,
+// чтобы не читать (и не декодировать) один и тот же месяц из хранилища для каждого дня.
+type monthCache struct {
+	f      Finder
+	months map[monthKey]store.Days
+}
+
+type monthKey struct {
+	y int
+	m time.Month
+}
+
+func newMonthCache(f Finder) *monthCache {
+	return &monthCache{
+		f:      f,
+		months: make(map[monthKey]store.Days, 2),
+	}
+}
+
+func (c *monthCache) month(y int, m time.Month) (store.Days, error) {
+	key := monthKey{y, m}
+	days, cached := c.months[key]
+	if !cached {
+		days, _ = c.f.FindMonth(y, m)
+		c.months[key] = days // Отсутствующий месяц тоже запоминается, как nil.
+	}
+
+	// Синхронизированный год всегда содержит все месяцы (см. source.Generic),
+	// поэтому отсутствие месяца означает, что не синхронизирован весь год.
+	if days == nil {
+		return nil, &MissingYearsError{Years: []int{y}}
+	}
+	return days, nil
+}
+
+// load читает в кеш все месяцы из диапазона [from, to]. В отличие от month, сообщает обо всех отсутствующих годах сразу.
+func (c *monthCache) load(from, to time.Time) error {
+	var missing []int
+	for date := store.NewDate(from.Year(), from.Month(), 1); !date.After(to); date = date.AddDate(0, 1, 0) {
+		if _, err := c.month(date.Year(), date.Month()); err != nil {
+			if len(missing) == 0 || missing[len(missing)-1] != date.Year() {
+				missing = append(missing, date.Year())
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingYearsError{Years: missing}
+	}
+	return nil
+}
+
+func (c *monthCache) day(date time.Time) (store.Day, error) {
+	days, err := c.month(date.Year(), date.Month())
+	if err != nil {
+		return store.Day{}, err
+	}
+
+	day, ok := days[date.Day()]
+	if !ok {
+		return store.Day{}, fmt.Errorf("%w: %s", ErrDateNotFound, date.Format(store.DateLayout))
+	}
+	return day, nil
+}
+
+func truncDate(t time.Time) time.Time {
+	return store.NewDate(t.Date())
+}
@@ -0,0 +1,180 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddWorkDays(t *testing.T) {
+	f := StoreMock{
+		2021: makeYear(2021),
+		2022: makeYear(2022, 1, 2, 3, 4, 5, 6, 7),
+	}
+
+	tests := []struct {
+		date string
+		n    int
+		exp  string
+	}{
+		{"2022-01-10", 0, "2022-01-10"},
+		{"2022-01-09", 0, "2022-01-09"}, // Нерабочий день, но n=0.
+		{"2022-01-10", 1, "2022-01-11"},
+		{"2022-01-14", 1, "2022-01-17"}, // Через выходные.
+		{"2022-01-17", -1, "2022-01-14"},
+		{"2021-12-30", 2, "2022-01-10"}, // Через год и новогодние праздники.
+		{"2022-01-10", -2, "2021-12-30"},
+	}
+
+	for _, tt := range tests {
+		date, _ := store.ParseDate(tt.date)
+		day, err := AddWorkDays(f, date, tt.n)
+		if assert.NoError(t, err, "%s %+d", tt.date, tt.n) {
+			assert.Equal(t, tt.exp, day.Date.Format(store.DateLayout), "%s %+d", tt.date, tt.n)
+		}
+	}
+}
+
+func TestAddWorkDays_missingYear(t *testing.T) {
+	f := StoreMock{2022: makeYear(2022)}
+
+	_, err := AddWorkDays(f, store.NewDate(2022, time.December, 30), 2)
+	var missingErr *MissingYearsError
+	if assert.ErrorAs(t, err, &missingErr) {
+		assert.Equal(t, []int{2023}, missingErr.Years)
+	}
+
+	// Год есть, но даты в нем нет.
+	f[2023] = store.Months{time.January: {2: {Working: true, Type: store.Normal}}}
+	_, err = AddWorkDays(f, store.NewDate(2022, time.December, 30), 2)
+	assert.ErrorIs(t, err, ErrDateNotFound)
+}
+
+func TestNextWorkDay(t *testing.T) {
+	f := StoreMock{
+		2021: makeYear(2021),
+		2022: makeYear(2022, 1, 2, 3, 4, 5, 6, 7),
+	}
+
+	tests := []struct {
+		date string
+		n    int
+		next string
+		prev string
+	}{
+		{"2022-01-10", 1, "2022-01-10", "2022-01-10"}, // Рабочий день.
+		{"2022-01-10", 2, "2022-01-11", "2021-12-31"},
+		{"2022-01-03", 1, "2022-01-10", "2021-12-31"}, // Праздник.
+		{"2022-01-03", 3, "2022-01-12", "2021-12-29"},
+	}
+
+	for _, tt := range tests {
+		date, _ := store.ParseDate(tt.date)
+
+		next, err := NextWorkDay(f, date, tt.n)
+		if assert.NoError(t, err, "next %s, n=%d", tt.date, tt.n) {
+			assert.Equal(t, tt.next, next.Date.Format(store.DateLayout), "next %s, n=%d", tt.date, tt.n)
+		}
+
+		prev, err := PrevWorkDay(f, date, tt.n)
+		if assert.NoError(t, err, "prev %s, n=%d", tt.date, tt.n) {
+			assert.Equal(t, tt.prev, prev.Date.Format(store.DateLayout), "prev %s, n=%d", tt.date, tt.n)
+		}
+	}
+
+	// Рабочий день 01.01.2021 найден, предыдущий год не нужен.
+	_, err := PrevWorkDay(f, store.NewDate(2021, time.January, 1), 1)
+	assert.NoError(t, err)
+
+	_, err = PrevWorkDay(f, store.NewDate(2021, time.January, 1), 2)
+	var missingErr *MissingYearsError
+	assert.ErrorAs(t, err, &missingErr)
+}
+
+func TestFindDays(t *testing.T) {
+	f := &countingFinder{f: StoreMock{
+		2022: makeYear(2022, 1, 2, 3, 4, 5, 6, 7),
+	}}
+
+	dates := []time.Time{
+		store.NewDate(2022, time.January, 3),
+		store.NewDate(2023, time.January, 1),
+		store.NewDate(2022, time.February, 1),
+		store.NewDate(2022, time.January, 10),
+		store.NewDate(2023, time.January, 2),
+	}
+
+	days := FindDays(f, dates)
+	expDays := []*store.Day{
+		{WeekDay: store.Monday, Working: false, Type: store.Holiday},
+		nil,
+		{WeekDay: store.Tuesday, Working: true, Type: store.Normal},
+		{WeekDay: store.Monday, Working: true, Type: store.Normal},
+		nil,
+	}
+	assert.Equal(t, expDays, days)
+
+	// 2022-01, 2022-02, 2023-01 — каждый месяц читается один раз, даже если его нет.
+	assert.Equal(t, 3, f.calls)
+}
+
+type countingFinder struct {
+	f     Finder
+	calls int
+}
+
+func (c *countingFinder) FindMonth(y int, mon time.Month) (store.Days, bool) {
+	c.calls++
+	return c.f.FindMonth(y, mon)
+}
+
+func TestRange(t *testing.T) {
+	f := StoreMock{
+		2021: makeYear(2021),
+		2022: makeYear(2022, 1, 2, 3, 4, 5, 6, 7),
+	}
+
+	days, err := Range(f, store.NewDate(2021, time.December, 31), store.NewDate(2022, time.January, 2))
+	assert.NoError(t, err)
+	expDays := []store.DateDay{
+		{Date: store.NewDate(2021, time.December, 31), Day: store.Day{WeekDay: store.Friday, Working: true, Type: store.Normal}},
+		{Date: store.NewDate(2022, time.January, 1), Day: store.Day{WeekDay: store.Saturday, Working: false, Type: store.Holiday}},
+		{Date: store.NewDate(2022, time.January, 2), Day: store.Day{WeekDay: store.Sunday, Working: false, Type: store.Holiday}},
+	}
+	assert.Equal(t, expDays, days)
+
+	// Перечисляются все отсутствующие годы.
+	_, err = Range(f, store.NewDate(2019, time.June, 1), store.NewDate(2024, time.June, 1))
+	var missingErr *MissingYearsError
+	if assert.ErrorAs(t, err, &missingErr) {
+		assert.Equal(t, []int{2019, 2020, 2023, 2024}, missingErr.Years)
+	}
+}
+
+// makeYear возвращает календарь, в котором сб и вс — выходные, а указанные дни января — праздники.
+func makeYear(y int, janHolidays ...int) store.Months {
+	months := make(store.Months, 12)
+	for date := store.NewDate(y, time.January, 1); date.Year() == y; date = date.AddDate(0, 0, 1) {
+		if months[date.Month()] == nil {
+			months[date.Month()] = make(store.Days, 31)
+		}
+
+		wd, _ := store.NewWeekDay(date.Weekday())
+		day := store.Day{WeekDay: wd, Working: true, Type: store.Normal}
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			day.Working = false
+			day.Type = store.Weekend
+		}
+		months[date.Month()][date.Day()] = day
+	}
+
+	for _, d := range janHolidays {
+		day := months[time.January][d]
+		day.Working = false
+		day.Type = store.Holiday
+		months[time.January][d] = day
+	}
+	return months
+}
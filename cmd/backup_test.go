@@ -59,8 +59,10 @@ func TestBackupCmd(t *testing.T) {
 	status, json := getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/2021/01/01", port))
 	expJson := `{
 		"weekDay": "fri",
-		"working": true,
-		"type": "normal"
+		"working": false,
+		"type": "holiday",
+		"desc": "Новогодние каникулы",
+		"provisional": true
 	}`
 	assert.Equal(t, 200, status)
 	assert.JSONEq(t, expJson, json)
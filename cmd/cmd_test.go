@@ -24,7 +24,7 @@ func newApp(t *testing.T, cmdMod func(*Server)) (cmd *Server, app *app, port int
 	cmd.Web.RateLimiter.ReqLimit = 100
 	cmd.Web.RateLimiter.LimitWindow = 1 * time.Second
 	cmd.Store.Engine = EngineType("memory")
-	cmd.Source.Parser = ParserType("none")
+	cmd.Source.Parser = []ParserType{ParserNone}
 	if cmdMod != nil {
 		cmdMod(cmd)
 	}
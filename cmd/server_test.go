@@ -1,13 +1,21 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/jessevdk/go-flags"
+	"github.com/nvkalinin/business-calendar/calendar"
+	"github.com/nvkalinin/business-calendar/notify"
+	"github.com/nvkalinin/business-calendar/source/parser"
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/nvkalinin/business-calendar/store/engine"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -37,12 +45,14 @@ func TestServerCmd_syncOnStart(t *testing.T) {
 	waitForHTTP(port)
 	time.Sleep(200 * time.Millisecond) // должно быть достаточно для generic календаря
 
-	// Из generic-календаря.
+	// Из generic-календаря: 1 января — праздник по ТК РФ, не подтвержденный другими источниками.
 	status, json := getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/2021/01/01", port))
 	expJson := `{
 		"weekDay": "fri",
-		"working": true,
-		"type": "normal"
+		"working": false,
+		"type": "holiday",
+		"desc": "Новогодние каникулы",
+		"provisional": true
 	}`
 	assert.Equal(t, 200, status)
 	assert.JSONEq(t, expJson, json)
@@ -58,6 +68,11 @@ func TestServerCmd_syncOnStart(t *testing.T) {
 	assert.Equal(t, 200, status)
 	assert.JSONEq(t, expJson, json)
 
+	// Год без парсера подтвержден только переопределениями.
+	status, json = getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/2021/status", port))
+	assert.Equal(t, 200, status)
+	assert.Contains(t, json, `"status":"partial"`)
+
 	y := time.Now().Year()
 
 	status, _ = getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/%d/01/01", port, y))
@@ -67,6 +82,178 @@ func TestServerCmd_syncOnStart(t *testing.T) {
 	assert.Equal(t, 200, status)
 }
 
+func TestServerCmd_regions(t *testing.T) {
+	_, a, port := newApp(t, func(cmd *Server) {
+		cmd.SyncOnStart = []string{"2021"}
+		cmd.Source.Override = "testdata/override.yml"
+		cmd.Source.Regions = []string{"tatarstan:testdata/tatarstan.yml"}
+	})
+	defer a.shutdown()
+
+	go a.run()
+	waitForHTTP(port)
+	time.Sleep(200 * time.Millisecond)
+
+	// Региональный праздник есть только в региональном календаре.
+	status, json := getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/tatarstan/2021/08/30", port))
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"weekDay": "mon", "working": false, "type": "holiday", "desc": "День Республики Татарстан"}`, json)
+
+	status, json = getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/2021/08/30", port))
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"weekDay": "mon", "working": true, "type": "normal", "provisional": true}`, json)
+
+	// Федеральные источники входят в региональный календарь.
+	status, json = getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/tatarstan/2021/01/02", port))
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"weekDay": "sat", "working": true, "type": "normal", "desc": "работаем"}`, json)
+}
+
+func TestServerCmd_countries(t *testing.T) {
+	_, a, port := newApp(t, func(cmd *Server) {
+		cmd.SyncOnStart = []string{"2022"}
+		cmd.Source.Countries = []string{"kz", "by"}
+	})
+	defer a.shutdown()
+
+	go a.run()
+	waitForHTTP(port)
+	time.Sleep(200 * time.Millisecond)
+
+	status, json := getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/kz/2022/03/22", port))
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"weekDay": "tue", "working": false, "type": "holiday", "desc": "Наурыз мейрамы", "provisional": true}`, json)
+
+	status, json = getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/by/2022/07/04", port))
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"weekDay": "mon", "working": true, "type": "normal", "provisional": true}`, json)
+
+	// Основной календарь доступен по коду ru.
+	status, _ = getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/ru/2022/03/22", port))
+	assert.Equal(t, 200, status)
+
+	status, _ = getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/uz/2022", port))
+	assert.Equal(t, 404, status)
+}
+
+func TestServerCmd_webhook(t *testing.T) {
+	payloads := make(chan notify.Payload, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p notify.Payload
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		payloads <- p
+	}))
+	defer receiver.Close()
+
+	_, a, port := newApp(t, func(cmd *Server) {
+		cmd.SyncOnStart = []string{"2021"}
+		cmd.Webhook.URLs = []string{receiver.URL}
+	})
+	defer a.shutdown()
+
+	go a.run()
+	waitForHTTP(port)
+
+	select {
+	case p := <-payloads:
+		assert.Equal(t, 2021, p.Year)
+		assert.True(t, p.Created)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	// Повторная синхронизация без изменений не вызывает вебхук.
+	cmd := newSyncCmd(port, []int{2021})
+	require.NoError(t, cmd.Execute([]string{}))
+	select {
+	case p := <-payloads:
+		t.Fatalf("unexpected webhook: %+v", p)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestServerCmd_consensus(t *testing.T) {
+	cmd := &Server{}
+	_, err := flags.ParseArgs(cmd, []string{
+		"--source.parser=consultant",
+		"--source.parser=superjob",
+		"--source.consensus=reject",
+	})
+	require.NoError(t, err)
+
+	src, err := cmd.makeSources()
+	require.NoError(t, err)
+	require.Len(t, src, 2)
+
+	consensus, ok := src[1].(*calendar.Consensus)
+	require.True(t, ok)
+	assert.Equal(t, calendar.ConsensusReject, consensus.Mode)
+	assert.Equal(t, "consensus:consultant+superjob", consensus.Name())
+
+	cmd = &Server{}
+	_, err = flags.ParseArgs(cmd, nil)
+	require.NoError(t, err)
+
+	src, err = cmd.makeSources()
+	require.NoError(t, err)
+	require.Len(t, src, 2)
+	assert.IsType(t, &parser.Consultant{}, src[1])
+}
+
+func TestServerCmd_xmlcalendar(t *testing.T) {
+	_, a, port := newApp(t, func(cmd *Server) {
+		cmd.SyncOnStart = []string{"2022"}
+		cmd.Source.Parser = []ParserType{ParserXMLCal}
+		cmd.Source.XMLCalendar.File = "../source/parser/testdata/xmlcalendar_{year}.xml"
+	})
+	defer a.shutdown()
+
+	go a.run()
+	waitForHTTP(port)
+	time.Sleep(200 * time.Millisecond)
+
+	status, json := getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/2022/05/03?explain=1", port))
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{
+		"weekDay": "tue",
+		"working": false,
+		"type": "weekend",
+		"desc": "Перенос выходного дня с 01.01",
+		"explain": {"weekDay": "xmlcalendar", "working": "xmlcalendar", "type": "xmlcalendar", "desc": "xmlcalendar"}
+	}`, json)
+}
+
+func TestServerCmd_decree(t *testing.T) {
+	_, a, port := newApp(t, func(cmd *Server) {
+		cmd.SyncOnStart = []string{"2022"}
+		cmd.Source.Decrees = []string{"../source/parser/testdata/decree_2022.txt"}
+	})
+	defer a.shutdown()
+
+	go a.run()
+	waitForHTTP(port)
+	time.Sleep(200 * time.Millisecond)
+
+	status, json := getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/2022/03/07?explain=1", port))
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{
+		"weekDay": "mon",
+		"working": false,
+		"type": "weekend",
+		"desc": "Перенос выходного дня с 05.03",
+		"explain": {"weekDay": "decree", "working": "decree", "type": "decree", "desc": "decree"}
+	}`, json)
+
+	status, json = getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/2022/03/05", port))
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{
+		"weekDay": "sat",
+		"working": true,
+		"type": "normal",
+		"desc": "Рабочий день, выходной перенесен на 07.03"
+	}`, json)
+}
+
 func TestServerCmd_autoSync(t *testing.T) {
 	_, a, port := newApp(t, func(cmd *Server) {
 		cmd.SyncAt = time.Now().Add(1 * time.Second).Format("15:04:05")
@@ -113,6 +300,13 @@ func TestServerCmd_fail(t *testing.T) {
 	_, err := cmd.makeApp()
 	assert.ErrorContains(t, err, "unknown store engine")
 
+	cmd = &Server{}
+	_, _ = flags.ParseArgs(cmd, []string{
+		"--store.engine=postgres",
+	})
+	_, err = cmd.makeApp()
+	assert.ErrorContains(t, err, "dsn is required")
+
 	cmd = &Server{}
 	_, _ = flags.ParseArgs(cmd, []string{
 		"--store.engine=memory",
@@ -121,6 +315,24 @@ func TestServerCmd_fail(t *testing.T) {
 	_, err = cmd.makeApp()
 	assert.ErrorContains(t, err, "unknown parser")
 
+	cmd = &Server{}
+	_, _ = flags.ParseArgs(cmd, []string{
+		"--store.engine=memory",
+		"--source.parser=consultant",
+		"--source.parser=none",
+	})
+	_, err = cmd.makeApp()
+	assert.ErrorContains(t, err, "cannot be combined")
+
+	cmd = &Server{}
+	_, _ = flags.ParseArgs(cmd, []string{
+		"--store.engine=memory",
+		"--source.parser=superjob",
+		"--source.parser=superjob",
+	})
+	_, err = cmd.makeApp()
+	assert.ErrorContains(t, err, "duplicate parser")
+
 	cmd = &Server{}
 	_, _ = flags.ParseArgs(cmd, []string{
 		"--store.engine=memory",
@@ -140,6 +352,43 @@ func TestServerCmd_fail(t *testing.T) {
 	_, err = cmd.makeApp()
 	assert.ErrorContains(t, err, "sync on start")
 
+	cmd = &Server{}
+	_, _ = flags.ParseArgs(cmd, []string{
+		"--store.engine=memory",
+		"--source.parser=none",
+		"--source.region=tatarstan",
+	})
+	_, err = cmd.makeApp()
+	assert.ErrorContains(t, err, "invalid region")
+
+	cmd = &Server{}
+	_, _ = flags.ParseArgs(cmd, []string{
+		"--store.engine=memory",
+		"--source.parser=none",
+		"--source.region=stats:stats.yml",
+	})
+	_, err = cmd.makeApp()
+	assert.ErrorContains(t, err, "reserved")
+
+	cmd = &Server{}
+	_, _ = flags.ParseArgs(cmd, []string{
+		"--store.engine=memory",
+		"--source.parser=none",
+		"--source.country=xx",
+	})
+	_, err = cmd.makeApp()
+	assert.ErrorContains(t, err, "unknown country")
+
+	cmd = &Server{}
+	_, _ = flags.ParseArgs(cmd, []string{
+		"--store.engine=memory",
+		"--source.parser=none",
+		"--source.region=kz:kz.yml",
+		"--source.country=kz",
+	})
+	_, err = cmd.makeApp()
+	assert.ErrorContains(t, err, "duplicate calendar")
+
 	cmd = &Server{}
 	_, _ = flags.ParseArgs(cmd, []string{
 		"--store.engine=memory",
@@ -150,3 +399,25 @@ func TestServerCmd_fail(t *testing.T) {
 	_, err = cmd.makeApp()
 	assert.NoError(t, err)
 }
+
+func TestServerCmd_makeStore_sqlite(t *testing.T) {
+	cmd := &Server{}
+	_, err := flags.ParseArgs(cmd, []string{
+		"--store.engine=sqlite",
+		"--store.sqlite.file=" + filepath.Join(t.TempDir(), "cal.sqlite"),
+	})
+	require.NoError(t, err)
+
+	st, err := cmd.makeStore()
+	require.NoError(t, err)
+	defer st.(*engine.SQLite).Close()
+
+	reg, err := calendarStore(st, "ba")
+	require.NoError(t, err)
+	require.NoError(t, reg.PutYear(2022, store.Months{time.March: {1: {WeekDay: store.Tuesday, Working: true, Type: store.Normal}}}))
+
+	_, ok := st.FindYear(2022)
+	assert.False(t, ok)
+	_, ok = reg.FindDay(2022, time.March, 1)
+	assert.True(t, ok)
+}
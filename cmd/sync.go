@@ -9,7 +9,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/nvkalinin/business-calendar/calendar"
 	"github.com/nvkalinin/business-calendar/log"
+	"github.com/nvkalinin/business-calendar/rest"
 )
 
 type Sync struct {
@@ -62,17 +64,39 @@ func (s *Sync) Execute(args []string) error {
 		log.Fatalf("[ERROR] sync error (status %d): %v", resp.StatusCode, err)
 	}
 
-	res := map[int]string{}
+	res := map[int]rest.SyncResult{}
 	if err := json.Unmarshal(respBody, &res); err != nil {
 		log.Fatalf("[ERROR] cannot parse response (status %d): %v", resp.StatusCode, err)
 	}
 
 	for y, syncRes := range res {
-		if syncRes == "ok" {
+		if syncRes.Status == "ok" {
 			log.Printf("[INFO] year %d: ok", y)
 		} else {
-			log.Printf("[ERROR] year %d: %s", y, syncRes)
+			log.Printf("[ERROR] year %d: %s", y, syncRes.Status)
 		}
+		logDiffs(syncRes.Diffs)
 	}
 	return nil
 }
+
+func logDiffs(diffs []calendar.Diff) {
+	for _, diff := range diffs {
+		name := diff.Calendar
+		if name == "" {
+			name = mainCalendarName
+		}
+
+		switch {
+		case diff.Created:
+			log.Printf("[INFO] calendar %s, year %d: created, %d day(s)", name, diff.Year, len(diff.Changes))
+		case !diff.Changed():
+			log.Printf("[INFO] calendar %s, year %d: no changes", name, diff.Year)
+		default:
+			log.Printf("[INFO] calendar %s, year %d: %d day(s) changed", name, diff.Year, len(diff.Changes))
+			for _, ch := range diff.Changes {
+				log.Printf("[INFO]   %s", ch)
+			}
+		}
+	}
+}
@@ -23,8 +23,10 @@ func TestSyncCmd(t *testing.T) {
 	status, json := getBody(t, fmt.Sprintf("http://localhost:%d/api/cal/2021/01/01", port))
 	expJson := `{
 		"weekDay": "fri",
-		"working": true,
-		"type": "normal"
+		"working": false,
+		"type": "holiday",
+		"desc": "Новогодние каникулы",
+		"provisional": true
 	}`
 	assert.Equal(t, 200, status)
 	assert.JSONEq(t, expJson, json)
@@ -33,7 +35,8 @@ func TestSyncCmd(t *testing.T) {
 	expJson = `{
 		"weekDay": "wed",
 		"working": true,
-		"type": "normal"
+		"type": "normal",
+		"provisional": true
 	}`
 	assert.Equal(t, 200, status)
 	assert.JSONEq(t, expJson, json)
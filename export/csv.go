@@ -0,0 +1,42 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+var csvHeader = []string{"date", "weekDay", "working", "type", "desc", "provisional"}
+
+// WriteCSV записывает дни в w в формате CSV: заголовок и по одной строке на каждую дату.
+// Значения полей совпадают со значениями в JSON, дата — в формате store.DateLayout.
+func WriteCSV(w io.Writer, days []store.DateDay) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("cannot write csv header: %w", err)
+	}
+
+	for _, d := range days {
+		rec := []string{
+			d.Date.Format(store.DateLayout),
+			string(d.WeekDay),
+			strconv.FormatBool(d.Working),
+			string(d.Type),
+			d.Desc,
+			strconv.FormatBool(d.Provisional),
+		}
+		if err := cw.Write(rec); err != nil {
+			return fmt.Errorf("cannot write csv record %s: %w", rec[0], err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("cannot write csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	days := []store.DateDay{
+		{Date: store.NewDate(2022, time.January, 1), Day: store.Day{WeekDay: store.Saturday, Working: false, Type: store.Holiday, Desc: "Новый год, Рождество"}},
+		{Date: store.NewDate(2022, time.January, 10), Day: store.Day{WeekDay: store.Monday, Working: true, Type: store.Normal, Provisional: true}},
+	}
+
+	buf := &bytes.Buffer{}
+	err := WriteCSV(buf, days)
+	require.NoError(t, err)
+
+	expCSV := "date,weekDay,working,type,desc,provisional\n" +
+		"2022-01-01,sat,false,holiday,\"Новый год, Рождество\",false\n" +
+		"2022-01-10,mon,true,normal,,true\n"
+	assert.Equal(t, expCSV, buf.String())
+}
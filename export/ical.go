@@ -0,0 +1,151 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// ICal — параметры календаря в формате iCalendar (RFC 5545).
+type ICal struct {
+	Name     string    // Название календаря, которое увидит подписчик.
+	Calendar string    // Код календаря (например, kz) для UID событий, пустое — основной календарь.
+	Stamp    time.Time // Время формирования календаря (DTSTAMP).
+}
+
+const (
+	icalLineLen = 75 // Максимальная длина строки в октетах без учета CRLF (RFC 5545, 3.1).
+	icalUIDHost = "business-calendar"
+)
+
+// WriteICal записывает в w календарь, в котором каждому праздничному, нерабочему и предпраздничному дню из days
+// соответствует событие на весь день. Остальные дни пропускаются.
+//
+// UID события зависит только от даты и кода календаря (cal.Calendar), поэтому при повторной синхронизации, если
+// изменится тип или описание дня, календарь подписчика обновит существующее событие, а не создаст новое. События
+// разных календарей на одну дату не совпадают, даже если подписаться на несколько календарей сразу.
+func WriteICal(w io.Writer, cal ICal, days []store.DateDay) error {
+	bw := bufio.NewWriter(w)
+	iw := &icalWriter{w: bw}
+
+	stamp := cal.Stamp.UTC().Format("20060102T150405Z")
+
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//nvkalinin//business-calendar//RU")
+	iw.line("CALSCALE:GREGORIAN")
+	iw.line("METHOD:PUBLISH")
+	if cal.Name != "" {
+		iw.line("X-WR-CALNAME:" + icalEscape(cal.Name))
+	}
+	iw.line("REFRESH-INTERVAL;VALUE=DURATION:P1D")
+	iw.line("X-PUBLISHED-TTL:P1D")
+
+	for _, d := range days {
+		summary, ok := icalSummary(d.Day)
+		if !ok {
+			continue
+		}
+
+		iw.line("BEGIN:VEVENT")
+		iw.line("UID:" + icalUID(cal.Calendar, d.Date))
+		iw.line("DTSTAMP:" + stamp)
+		iw.line("DTSTART;VALUE=DATE:" + d.Date.Format("20060102"))
+		iw.line("DTEND;VALUE=DATE:" + d.Date.AddDate(0, 0, 1).Format("20060102"))
+		iw.line("SUMMARY:" + icalEscape(summary))
+		iw.line("CATEGORIES:" + icalEscape(string(d.Type)))
+		iw.line("TRANSP:TRANSPARENT")
+		iw.line("END:VEVENT")
+	}
+
+	iw.line("END:VCALENDAR")
+
+	if iw.err != nil {
+		return fmt.Errorf("cannot write icalendar: %w", iw.err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot write icalendar: %w", err)
+	}
+	return nil
+}
+
+// icalUID возвращает UID события календаря cal на дату date, например, 20220101.kz@business-calendar.
+// У основного календаря код в UID не указывается.
+func icalUID(cal string, date time.Time) string {
+	if cal == "" {
+		return fmt.Sprintf("%s@%s", date.Format("20060102"), icalUIDHost)
+	}
+	return fmt.Sprintf("%s.%s@%s", date.Format("20060102"), cal, icalUIDHost)
+}
+
+// icalSummary возвращает название события для дня или false, если день не нужно выводить в календарь.
+func icalSummary(d store.Day) (string, bool) {
+	var summary string
+	switch d.Type {
+	case store.Holiday:
+		summary = "Праздничный день"
+	case store.NonWorking:
+		summary = "Нерабочий день"
+	case store.PreHoliday:
+		summary = "Предпраздничный день (сокращенный)"
+	default:
+		return "", false
+	}
+
+	if d.Desc != "" {
+		summary = d.Desc
+	}
+	return summary, true
+}
+
+// icalWriter записывает строки iCalendar, разделенные CRLF, и переносит длинные строки (RFC 5545, 3.1).
+// Первая ошибка запоминается, последующие записи игнорируются.
+type icalWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (iw *icalWriter) line(s string) {
+	if iw.err != nil {
+		return
+	}
+	_, iw.err = io.WriteString(iw.w, icalFold(s)+"\r\n")
+}
+
+// icalFold разбивает строку на части не длиннее icalLineLen октетов, не разрывая UTF-8 символы.
+// Каждая следующая часть начинается с пробела.
+func icalFold(s string) string {
+	if len(s) <= icalLineLen {
+		return s
+	}
+
+	var sb strings.Builder
+	lineLen := 0
+	for _, r := range s {
+		rLen := utf8.RuneLen(r)
+		if lineLen+rLen > icalLineLen {
+			sb.WriteString("\r\n ")
+			lineLen = 1
+		}
+		sb.WriteRune(r)
+		lineLen += rLen
+	}
+	return sb.String()
+}
+
+var icalEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func icalEscape(s string) string {
+	return icalEscaper.Replace(s)
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteICal(t *testing.T) {
+	days := []store.DateDay{
+		{Date: store.NewDate(2021, time.December, 31), Day: store.Day{WeekDay: store.Friday, Working: true, Type: store.Normal}},
+		{Date: store.NewDate(2022, time.January, 1), Day: store.Day{WeekDay: store.Saturday, Working: false, Type: store.Holiday, Desc: "Новый год, Рождество"}},
+		{Date: store.NewDate(2022, time.January, 2), Day: store.Day{WeekDay: store.Sunday, Working: false, Type: store.Weekend}},
+		{Date: store.NewDate(2022, time.February, 22), Day: store.Day{WeekDay: store.Tuesday, Working: true, Type: store.PreHoliday}},
+	}
+
+	buf := &bytes.Buffer{}
+	err := WriteICal(buf, ICal{Name: "Производственный календарь", Stamp: time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)}, days)
+	require.NoError(t, err)
+
+	expICal := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//nvkalinin//business-calendar//RU",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Производственный календарь",
+		"REFRESH-INTERVAL;VALUE=DURATION:P1D",
+		"X-PUBLISHED-TTL:P1D",
+		"BEGIN:VEVENT",
+		"UID:20220101@business-calendar",
+		"DTSTAMP:20220501T100000Z",
+		"DTSTART;VALUE=DATE:20220101",
+		"DTEND;VALUE=DATE:20220102",
+		`SUMMARY:Новый год\, Рождество`,
+		"CATEGORIES:holiday",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:20220222@business-calendar",
+		"DTSTAMP:20220501T100000Z",
+		"DTSTART;VALUE=DATE:20220222",
+		"DTEND;VALUE=DATE:20220223",
+		"SUMMARY:Предпраздничный день (сокращенный)",
+		"CATEGORIES:preHoliday",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	assert.Equal(t, expICal, buf.String())
+}
+
+func TestICalUID(t *testing.T) {
+	date := store.NewDate(2026, time.January, 1)
+	assert.Equal(t, "20260101@business-calendar", icalUID("", date))
+	assert.Equal(t, "20260101.kz@business-calendar", icalUID("kz", date))
+}
+
+func TestICalFold(t *testing.T) {
+	// 40 кириллических символов = 80 октетов.
+	s := "SUMMARY:" + strings.Repeat("я", 40)
+	folded := icalFold(s)
+
+	lines := strings.Split(folded, "\r\n")
+	require.Len(t, lines, 2)
+	assert.LessOrEqual(t, len(lines[0]), icalLineLen)
+	assert.True(t, strings.HasPrefix(lines[1], " "))
+	assert.Equal(t, s, lines[0]+strings.TrimPrefix(lines[1], " "))
+}
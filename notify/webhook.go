@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/calendar"
+	"github.com/nvkalinin/business-calendar/log"
+)
+
+const (
+	EventCalendarChanged = "calendar.changed"
+
+	SignatureHeader = "X-Signature-256" // sha256=<hex HMAC-SHA256 тела запроса>.
+	EventHeader     = "X-Webhook-Event"
+)
+
+// Webhook отправляет изменения календаря POST-запросом с JSON на каждый из URLs.
+// Если задан Secret, тело запроса подписывается HMAC-SHA256, подпись передается в заголовке SignatureHeader.
+//
+// Доставка выполняется в фоне, чтобы недоступный получатель не задерживал синхронизацию.
+// При ошибке сети, ответе 5xx или 429 запрос повторяется до Retries раз, пауза между попытками
+// начинается с Backoff и удваивается после каждой попытки.
+//
+// После Shutdown новые изменения не отправляются.
+type Webhook struct {
+	URLs    []string
+	Secret  string
+	Client  *http.Client
+	Retries int
+	Backoff time.Duration
+
+	once   sync.Once
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex // Защищает closed и wg.Add от гонки с Shutdown.
+	closed bool
+}
+
+// Payload — тело запроса.
+type Payload struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	calendar.Diff
+}
+
+func (w *Webhook) init() {
+	w.once.Do(func() {
+		w.stopCh = make(chan struct{})
+	})
+}
+
+// Notify реализует calendar.Notifier.
+func (w *Webhook) Notify(diff calendar.Diff) {
+	w.init()
+
+	body, err := json.Marshal(Payload{
+		Event: EventCalendarChanged,
+		Time:  time.Now().UTC(),
+		Diff:  diff,
+	})
+	if err != nil {
+		log.Printf("[WARN] notify/webhook cannot marshal payload: %v", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		log.Printf("[WARN] notify/webhook is shut down, changes of year %d are not delivered", diff.Year)
+		return
+	}
+
+	for _, url := range w.URLs {
+		url := url
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.deliver(url, body)
+		}()
+	}
+}
+
+// Shutdown прерывает ожидание повторных попыток и ждет завершения начатых запросов.
+// Повторный вызов только ждет завершения запросов.
+func (w *Webhook) Shutdown(ctx context.Context) error {
+	w.init()
+
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stopCh)
+	}
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		log.Printf("[WARN] notify/webhook shutdown timeout")
+		return ctx.Err()
+	}
+}
+
+func (w *Webhook) deliver(url string, body []byte) {
+	backoff := w.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.send(url, body)
+		if err == nil {
+			log.Printf("[INFO] notify/webhook delivered to %s", url)
+			return
+		}
+		if !retry || attempt >= w.Retries {
+			log.Printf("[WARN] notify/webhook delivery to %s failed after %d attempt(s): %v", url, attempt+1, err)
+			return
+		}
+		log.Printf("[DEBUG] notify/webhook delivery to %s failed, retrying in %s: %v", url, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-w.stopCh:
+			log.Printf("[WARN] notify/webhook delivery to %s canceled: shutting down", url)
+			return
+		}
+	}
+}
+
+// send отправляет один запрос. retry=true, если ошибка временная и запрос можно повторить.
+func (w *Webhook) send(url string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("cannot make request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, EventCalendarChanged)
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("[WARN] notify/webhook cannot close response: %v", err)
+		}
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+}
+
+// Sign возвращает значение заголовка SignatureHeader для тела body.
+// Получатель должен вычислить подпись тем же способом и сравнить ее с заголовком через hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
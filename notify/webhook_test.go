@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/calendar"
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDiff = calendar.Diff{
+	Calendar: "tatarstan",
+	Year:     2022,
+	Changes: []calendar.DayChange{{
+		Date: "2022-08-30",
+		Kind: calendar.DayChanged,
+		Old:  &store.Day{WeekDay: store.Tuesday, Working: true, Type: store.Normal},
+		New:  &store.Day{WeekDay: store.Tuesday, Working: false, Type: store.Holiday},
+	}},
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	wh := &Webhook{URLs: []string{srv.URL}, Secret: "secret"}
+	wh.Notify(testDiff)
+
+	var req *http.Request
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	body := <-bodies
+
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, EventCalendarChanged, req.Header.Get(EventHeader))
+	assert.True(t, hmac.Equal([]byte(Sign("secret", body)), []byte(req.Header.Get(SignatureHeader))))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, EventCalendarChanged, payload.Event)
+	assert.Equal(t, testDiff, payload.Diff)
+	assert.False(t, payload.Time.IsZero())
+
+	require.NoError(t, wh.Shutdown(context.Background()))
+}
+
+func TestWebhook_retries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	wh := &Webhook{URLs: []string{srv.URL}, Retries: 5, Backoff: 10 * time.Millisecond}
+	wh.Notify(testDiff)
+	wh.wg.Wait()
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+	// Ошибка клиента не повторяется.
+	atomic.StoreInt32(&calls, 0)
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	wh.Notify(testDiff)
+	wh.wg.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// Попытки ограничены.
+	atomic.StoreInt32(&calls, 0)
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	wh.Notify(testDiff)
+	wh.wg.Wait()
+	assert.EqualValues(t, 6, atomic.LoadInt32(&calls))
+}
+
+func TestWebhook_Shutdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	wh := &Webhook{URLs: []string{srv.URL}, Retries: 5, Backoff: time.Hour}
+	wh.Notify(testDiff)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, wh.Shutdown(ctx)) // Не ждет час до повторной попытки.
+}
+
+func TestWebhook_Shutdown_twice(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer srv.Close()
+
+	wh := &Webhook{URLs: []string{srv.URL}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, wh.Shutdown(ctx))
+	assert.NoError(t, wh.Shutdown(ctx))
+
+	// После Shutdown изменения не отправляются.
+	wh.Notify(testDiff)
+	assert.NoError(t, wh.Shutdown(ctx))
+	assert.EqualValues(t, 0, atomic.LoadInt32(&calls))
+}
+
+func TestWebhook_NotifyDuringShutdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	wh := &Webhook{URLs: []string{srv.URL}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wh.Notify(testDiff)
+		}()
+	}
+	assert.NoError(t, wh.Shutdown(ctx))
+	wg.Wait()
+}
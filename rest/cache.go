@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// checkNotModified задает заголовки ETag и Last-Modified по метаданным года, к которому относится ответ, и проверяет
+// условия If-None-Match и If-Modified-Since. Если клиент уже получил актуальную версию ответа,
+// отправляет 304 Not Modified и возвращает true — в этом случае обработчик должен завершиться.
+//
+// ETag зависит от содержимого года и запроса (путь, параметры, Accept), поскольку из одного года
+// формируются разные ответы: год, месяц, день, JSON или CSV. Поэтому и в ответе 304 задается Vary: Accept,
+// как в ответах с данными.
+func checkNotModified(w http.ResponseWriter, r *http.Request, meta *store.YearMeta) bool {
+	etag := makeETag(meta.Hash, r.URL.RequestURI(), r.Header.Get("Accept"))
+	modified := meta.Modified.UTC().Truncate(time.Second)
+
+	w.Header().Set("Vary", "Accept")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "no-cache") // Кеш должен каждый раз проверять актуальность ответа.
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		// If-None-Match имеет приоритет над If-Modified-Since (RFC 7232, 6).
+		if !etagMatch(inm, etag) {
+			return false
+		}
+	} else {
+		ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || modified.After(ims) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func makeETag(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatch сравнивает ETag со значением If-None-Match. Используется слабое сравнение (RFC 7232, 2.3.2).
+func etagMatch(ifNoneMatch string, etag string) bool {
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
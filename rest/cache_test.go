@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/nvkalinin/business-calendar/store/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ConditionalGet(t *testing.T) {
+	st := engine.NewMemory()
+	_ = st.PutYear(2022, store.Months{
+		time.January: {
+			1: {WeekDay: store.Saturday, Working: false, Type: store.Holiday},
+		},
+	})
+
+	rest := &Server{Store: st, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	for _, path := range []string{"/api/cal/2022", "/api/cal/2022/1", "/api/cal/2022/1/1"} {
+		resp := doGet(t, srv.URL+path, nil)
+		assert.Equal(t, 200, resp.StatusCode, path)
+		etag := resp.Header.Get("ETag")
+		lastMod := resp.Header.Get("Last-Modified")
+		assert.NotEmpty(t, etag, path)
+		assert.NotEmpty(t, lastMod, path)
+
+		resp = doGet(t, srv.URL+path, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, 304, resp.StatusCode, path)
+		assert.Equal(t, etag, resp.Header.Get("ETag"), path)
+		assert.Equal(t, "Accept", resp.Header.Get("Vary"), path)
+
+		resp = doGet(t, srv.URL+path, map[string]string{"If-Modified-Since": lastMod})
+		assert.Equal(t, 304, resp.StatusCode, path)
+
+		resp = doGet(t, srv.URL+path, map[string]string{"If-None-Match": `"foo"`})
+		assert.Equal(t, 200, resp.StatusCode, path)
+
+		// Другой формат ответа — другой ETag.
+		resp = doGet(t, srv.URL+path+"?layout=flat", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, 200, resp.StatusCode, path)
+	}
+
+	resp := doGet(t, srv.URL+"/api/cal/2022", nil)
+	etag := resp.Header.Get("ETag")
+
+	// Повторная синхронизация без изменений не меняет ETag.
+	y, _ := st.FindYear(2022)
+	_ = st.PutYear(2022, y)
+	resp = doGet(t, srv.URL+"/api/cal/2022", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, 304, resp.StatusCode)
+
+	// Календарь изменился.
+	y[time.January][1] = store.Day{WeekDay: store.Saturday, Working: true, Type: store.Normal}
+	_ = st.PutYear(2022, y)
+	resp = doGet(t, srv.URL+"/api/cal/2022", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+
+	// У ошибок нет ETag.
+	resp = doGet(t, srv.URL+"/api/cal/2022/1/2", nil)
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("ETag"))
+}
+
+func doGet(t *testing.T, url string, headers map[string]string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return resp
+}
@@ -0,0 +1,193 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/calendar"
+	"github.com/nvkalinin/business-calendar/log"
+)
+
+const (
+	eventsBuffer       = 16               // Сколько событий может ждать отправки одному подписчику.
+	eventsPingInterval = 30 * time.Second // Как часто отправлять комментарий, чтобы обнаружить отключившихся клиентов.
+	eventsWriteTimeout = 10 * time.Second // Максимальное время записи одного события.
+)
+
+// Events рассылает подписчикам /api/events изменения календарей (Server-Sent Events).
+// Реализует calendar.Notifier.
+type Events struct {
+	mu     sync.Mutex
+	subs   map[chan []byte]struct{}
+	nextID int
+	closed bool
+}
+
+// CalendarEvent — событие об изменении календаря за год.
+type CalendarEvent struct {
+	Calendar string   `json:"calendar,omitempty"` // Пустое — основной календарь.
+	Year     int      `json:"year"`
+	Created  bool     `json:"created,omitempty"`
+	Changed  int      `json:"changed"`         // Количество измененных дней.
+	Dates    []string `json:"dates,omitempty"` // Измененные даты; не заполняется, если год сохранен впервые.
+}
+
+func NewEvents() *Events {
+	return &Events{
+		subs: make(map[chan []byte]struct{}),
+	}
+}
+
+// Notify отправляет событие всем подписчикам. Если подписчик не успевает читать события, событие для него
+// пропускается, чтобы не задерживать синхронизацию.
+func (e *Events) Notify(diff calendar.Diff) {
+	ev := CalendarEvent{
+		Calendar: diff.Calendar,
+		Year:     diff.Year,
+		Created:  diff.Created,
+		Changed:  len(diff.Changes),
+	}
+	if !diff.Created {
+		for _, ch := range diff.Changes {
+			ev.Dates = append(ev.Dates, ch.Date)
+		}
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("[WARN] events: cannot marshal event: %v", err)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.nextID++
+	msg := []byte(fmt.Sprintf("id: %d\nevent: calendar\ndata: %s\n\n", e.nextID, data))
+
+	for sub := range e.subs {
+		select {
+		case sub <- msg:
+		default:
+			log.Printf("[WARN] events: subscriber is too slow, event %d dropped", e.nextID)
+		}
+	}
+}
+
+// Close отключает всех подписчиков. Соединения подписчиков перехвачены у http.Server (см. eventsCtrl),
+// поэтому http.Server.Shutdown их не закрывает.
+func (e *Events) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.closed = true
+	for sub := range e.subs {
+		close(sub)
+		delete(e.subs, sub)
+	}
+}
+
+func (e *Events) subscribe() (ch chan []byte, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return nil, false
+	}
+
+	ch = make(chan []byte, eventsBuffer)
+	e.subs[ch] = struct{}{}
+	return ch, true
+}
+
+func (e *Events) unsubscribe(ch chan []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.subs[ch]; ok {
+		close(ch)
+		delete(e.subs, ch)
+	}
+}
+
+// eventsCtrl — поток событий об изменении календарей (Server-Sent Events).
+//
+// http.Server.WriteTimeout ограничивает время записи всего ответа, поэтому длинный поток был бы прерван.
+// Чтобы этого избежать, соединение перехватывается (http.Hijacker): для него снимаются таймауты сервера,
+// а на запись каждого события устанавливается свой таймаут eventsWriteTimeout.
+func (s *Server) eventsCtrl(w http.ResponseWriter, r *http.Request) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		sendErrorJson(w, 500, "streaming is not supported")
+		return
+	}
+
+	sub, ok := s.Events.subscribe()
+	if !ok {
+		sendErrorJson(w, 503, "server is shutting down")
+		return
+	}
+	defer s.Events.unsubscribe(sub)
+
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		log.Printf("[WARN] events: cannot hijack connection: %v", err)
+		return
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Printf("[DEBUG] events: cannot close connection: %v", err)
+		}
+	}()
+	_ = conn.SetDeadline(time.Time{})
+
+	// Клиент не должен ничего присылать, поэтому чтение завершится, только когда он отключится.
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, rw.Reader)
+		close(gone)
+	}()
+
+	send := func(msg string) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(eventsWriteTimeout))
+		if _, err := rw.WriteString(msg); err != nil {
+			return false
+		}
+		return rw.Flush() == nil
+	}
+
+	header := "HTTP/1.1 200 OK\r\n" +
+		"Content-Type: text/event-stream\r\n" +
+		"Cache-Control: no-cache\r\n" +
+		"Connection: close\r\n" +
+		"\r\n" +
+		": connected\n\n"
+	if !send(header) {
+		return
+	}
+
+	ping := time.NewTicker(eventsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg, ok := <-sub:
+			if !ok {
+				return
+			}
+			if !send(string(msg)) {
+				return
+			}
+		case <-ping.C:
+			if !send(": ping\n\n") {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
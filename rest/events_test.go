@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Events(t *testing.T) {
+	events := NewEvents()
+	rest := &Server{Store: testStore, Events: events, Opts: testOpts}
+
+	srv := httptest.NewUnstartedServer(rest.routes())
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := make(chan string, 10)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if strings.HasPrefix(sc.Text(), "data: ") {
+				lines <- strings.TrimPrefix(sc.Text(), "data: ")
+			}
+		}
+		close(lines)
+	}()
+
+	// Поток не прерывается по WriteTimeout.
+	time.Sleep(300 * time.Millisecond)
+
+	events.Notify(calendar.Diff{
+		Calendar: "kz",
+		Year:     2022,
+		Changes: []calendar.DayChange{
+			{Date: "2022-05-02", Kind: calendar.DayChanged},
+			{Date: "2022-05-03", Kind: calendar.DayChanged},
+		},
+	})
+	events.Notify(calendar.Diff{Year: 2023, Created: true, Changes: make([]calendar.DayChange, 365)})
+
+	expEvents := []string{
+		`{"calendar": "kz", "year": 2022, "changed": 2, "dates": ["2022-05-02", "2022-05-03"]}`,
+		`{"year": 2023, "created": true, "changed": 365}`,
+	}
+	for _, exp := range expEvents {
+		select {
+		case data, ok := <-lines:
+			require.True(t, ok, "stream closed")
+			assert.JSONEq(t, exp, data)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not received")
+		}
+	}
+
+	// Close отключает подписчиков.
+	events.Close()
+	select {
+	case _, ok := <-lines:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream was not closed")
+	}
+}
+
+func TestServer_Events_disabled(t *testing.T) {
+	rest := &Server{Store: testStore, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	status, _ := getBody(t, srv.URL+"/api/events")
+	assert.Equal(t, 404, status)
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// explainedDay — день вместе с названиями источников, которые определили его поля (?explain=1).
+// Explain пуст, если год был сохранен без сведений об источниках.
+type explainedDay struct {
+	store.Day
+	Explain *store.DaySource `json:"explain,omitempty"`
+}
+
+type explainedDateDay struct {
+	Date string `json:"date"`
+	explainedDay
+}
+
+// explainParam читает параметр explain (1/true/0/false).
+func explainParam(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("explain")
+	if v == "" {
+		return false, nil
+	}
+
+	explain, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid 'explain', expected 1 or 0")
+	}
+	return explain, nil
+}
+
+func explainDay(day store.Day, prov store.Provenance, mon time.Month, d int) explainedDay {
+	res := explainedDay{Day: day}
+	if src, ok := prov[mon][d]; ok {
+		res.Explain = &src
+	}
+	return res
+}
+
+// sendExplainedDays отправляет дни месяца mon года y вместе с источниками их полей.
+// Поддерживаются оба варианта JSON (layout), CSV — нет.
+func (s *Server) sendExplainedDays(w http.ResponseWriter, r *http.Request, y int, mon time.Month, days store.Days) {
+	format, err1 := responseFormat(r)
+	layout, err2 := layoutParam(r)
+	if err := combineErrors(err1, err2); err != nil {
+		sendErrorJson(w, 400, err.Error())
+		return
+	}
+	if format == formatCSV {
+		sendErrorJson(w, 400, "'explain' is not supported for CSV")
+		return
+	}
+	w.Header().Set("Vary", "Accept")
+
+	prov, _ := s.Store.FindProvenance(y)
+
+	if layout == layoutFlat {
+		flat := make([]explainedDateDay, 0, len(days))
+		for _, dd := range days.DateDays(y, mon) {
+			flat = append(flat, explainedDateDay{
+				Date:         dd.Date.Format(store.DateLayout),
+				explainedDay: explainDay(dd.Day, prov, mon, dd.Date.Day()),
+			})
+		}
+		sendJsonResponse(w, flat)
+		return
+	}
+
+	nested := make(map[int]explainedDay, len(days))
+	for d, day := range days {
+		nested[d] = explainDay(day, prov, mon, d)
+	}
+	sendJsonResponse(w, nested)
+}
@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/nvkalinin/business-calendar/store/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_explain(t *testing.T) {
+	st := engine.NewMemory()
+	rev := store.NewRevision(store.Months{time.January: {
+		1: {WeekDay: store.Saturday, Working: false, Type: store.Holiday, Desc: "Новый год"},
+		3: {WeekDay: store.Monday, Working: false, Type: store.Holiday},
+	}}, []string{"generic", "consultant", "override:cal.yml"})
+	rev.Provenance = store.Provenance{time.January: {
+		1: {WeekDay: "generic", Working: "consultant", Type: "consultant", Desc: "override:cal.yml"},
+		3: {WeekDay: "generic", Working: "consultant", Type: "consultant"},
+	}}
+	require.NoError(t, st.PutRevision(2022, rev))
+	require.NoError(t, st.PutYear(2021, store.Months{time.January: {
+		1: {WeekDay: store.Friday, Working: false, Type: store.Holiday},
+	}}))
+
+	rest := &Server{Store: st, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	status, body := getBody(t, srv.URL+"/api/cal/2022/1/1?explain=1")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{
+		"weekDay": "sat", "working": false, "type": "holiday", "desc": "Новый год",
+		"explain": {"weekDay": "generic", "working": "consultant", "type": "consultant", "desc": "override:cal.yml"}
+	}`, body)
+
+	status, body = getBody(t, srv.URL+"/api/cal/2022/1?explain=true")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{
+		"1": {
+			"weekDay": "sat", "working": false, "type": "holiday", "desc": "Новый год",
+			"explain": {"weekDay": "generic", "working": "consultant", "type": "consultant", "desc": "override:cal.yml"}
+		},
+		"3": {
+			"weekDay": "mon", "working": false, "type": "holiday",
+			"explain": {"weekDay": "generic", "working": "consultant", "type": "consultant"}
+		}
+	}`, body)
+
+	status, body = getBody(t, srv.URL+"/api/cal/2022/1?explain=1&layout=flat")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `[
+		{
+			"date": "2022-01-01", "weekDay": "sat", "working": false, "type": "holiday", "desc": "Новый год",
+			"explain": {"weekDay": "generic", "working": "consultant", "type": "consultant", "desc": "override:cal.yml"}
+		},
+		{
+			"date": "2022-01-03", "weekDay": "mon", "working": false, "type": "holiday",
+			"explain": {"weekDay": "generic", "working": "consultant", "type": "consultant"}
+		}
+	]`, body)
+
+	// Без explain ответ не меняется.
+	status, body = getBody(t, srv.URL+"/api/cal/2022/1/3?explain=0")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"weekDay": "mon", "working": false, "type": "holiday"}`, body)
+
+	// Год сохранен без сведений об источниках.
+	status, body = getBody(t, srv.URL+"/api/cal/2021/1/1?explain=1")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"weekDay": "fri", "working": false, "type": "holiday"}`, body)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2022/1?explain=1&format=csv")
+	assert.Equal(t, 400, status)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2022/1/1?explain=foo")
+	assert.Equal(t, 400, status)
+}
@@ -0,0 +1,190 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/export"
+	"github.com/nvkalinin/business-calendar/log"
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// icalFeedYears — сколько лет до и после текущего входит в общий календарь /api/cal.ics.
+const icalFeedYears = 1
+
+const icalName = "Производственный календарь"
+
+// Форматы ответа, которые можно выбрать параметром format или заголовком Accept.
+const (
+	formatJson = "json"
+	formatCSV  = "csv"
+)
+
+// Представления календаря в JSON, которые можно выбрать параметром layout.
+const (
+	layoutNested = "nested" // map: номер месяца -> номер дня -> день (по умолчанию).
+	layoutFlat   = "flat"   // Упорядоченный массив дней с полем date.
+)
+
+// sendDays отправляет календарь в формате и представлении, которые запросил клиент.
+// nested — календарь в представлении по умолчанию, days — те же дни, упорядоченные по дате.
+// fileName — имя файла без расширения, если клиент запросил выгрузку в файл (CSV).
+func sendDays(w http.ResponseWriter, r *http.Request, fileName string, nested interface{}, days []store.DateDay) {
+	format, err1 := responseFormat(r)
+	layout, err2 := layoutParam(r)
+	if err := combineErrors(err1, err2); err != nil {
+		sendErrorJson(w, 400, err.Error())
+		return
+	}
+	w.Header().Set("Vary", "Accept")
+
+	switch {
+	case format == formatCSV:
+		sendCSV(w, fileName+".csv", days)
+	case layout == layoutFlat:
+		sendJsonResponse(w, days)
+	default:
+		sendJsonResponse(w, nested)
+	}
+}
+
+func layoutParam(r *http.Request) (string, error) {
+	switch l := r.URL.Query().Get("layout"); l {
+	case "", layoutNested:
+		return layoutNested, nil
+	case layoutFlat:
+		return layoutFlat, nil
+	default:
+		return "", fmt.Errorf("unknown layout '%s'", l)
+	}
+}
+
+// responseFormat определяет формат ответа: параметр format имеет приоритет над заголовком Accept.
+// Из Accept выбирается известный формат с наибольшим весом (q), при равных весах — первый из перечисленных;
+// форматы с q=0 не выбираются. По умолчанию — JSON.
+func responseFormat(r *http.Request) (string, error) {
+	if f := r.URL.Query().Get("format"); f != "" {
+		switch f {
+		case formatJson, formatCSV:
+			return f, nil
+		default:
+			return "", fmt.Errorf("unknown format '%s'", f)
+		}
+	}
+
+	format, maxQ := formatJson, 0.0
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+
+		var f string
+		switch mediaType {
+		case "application/json":
+			f = formatJson
+		case "text/csv":
+			f = formatCSV
+		default:
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > maxQ {
+			format, maxQ = f, q
+		}
+	}
+	return format, nil
+}
+
+// yearICalCtrl возвращает календарь за один год в формате iCalendar.
+func (s *Server) yearICalCtrl(w http.ResponseWriter, r *http.Request) {
+	y, err := yearParam(r)
+	if err != nil {
+		sendErrorJson(w, 400, "invalid year")
+		return
+	}
+
+	year, found := s.Store.FindYear(y)
+	if !found {
+		sendErrorJson(w, 404, "year not found")
+		return
+	}
+
+	sendICal(w, s.ical(), year.DateDays(y))
+}
+
+// icalFeedCtrl возвращает календарь в формате iCalendar за предыдущий, текущий и следующий год
+// (/api/cal.ics или /api/cal/<name>.ics для именованного календаря).
+// На этот календарь удобно подписаться: он всегда актуален и не требует смены URL в начале года.
+// Несинхронизированные годы пропускаются.
+func (s *Server) icalFeedCtrl(w http.ResponseWriter, r *http.Request) {
+	curYear := time.Now().Year()
+
+	var days []store.DateDay
+	for y := curYear - icalFeedYears; y <= curYear+icalFeedYears; y++ {
+		year, found := s.Store.FindYear(y)
+		if !found {
+			log.Printf("[DEBUG] ical feed: skipping year %d, not found", y)
+			continue
+		}
+		days = append(days, year.DateDays(y)...)
+	}
+
+	sendICal(w, s.ical(), days)
+}
+
+// ical возвращает параметры календаря s в формате iCalendar.
+// UID событий основного календаря содержат его код (MainCode), поэтому совпадают на /api/cal/... и
+// /api/cal/<MainCode>/..., если основной календарь доступен и по коду.
+func (s *Server) ical() export.ICal {
+	cal := export.ICal{Name: icalName, Calendar: s.MainCode, Stamp: time.Now()}
+	if s.calName != "" {
+		cal.Name = fmt.Sprintf("%s (%s)", icalName, s.calName)
+		cal.Calendar = s.calName
+	}
+	return cal
+}
+
+func sendICal(w http.ResponseWriter, cal export.ICal, days []store.DateDay) {
+	buf := &bytes.Buffer{}
+	if err := export.WriteICal(buf, cal, days); err != nil {
+		log.Printf("[WARN] cannot make icalendar: %+v", err)
+		sendErrorJson(w, 500, "cannot make icalendar")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(200)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("[WARN] cannot write response data: %+v", err)
+	}
+}
+
+func sendCSV(w http.ResponseWriter, fileName string, days []store.DateDay) {
+	buf := &bytes.Buffer{}
+	if err := export.WriteCSV(buf, days); err != nil {
+		log.Printf("[WARN] cannot make csv: %+v", err)
+		sendErrorJson(w, 500, "cannot make csv")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	w.WriteHeader(200)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("[WARN] cannot write response data: %+v", err)
+	}
+}
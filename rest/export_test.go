@@ -0,0 +1,190 @@
+package rest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/source"
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/nvkalinin/business-calendar/store/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_YearICal(t *testing.T) {
+	rest := &Server{Store: testStore, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/cal/2022.ics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	ical := string(body)
+	assert.True(t, strings.HasPrefix(ical, "BEGIN:VCALENDAR\r\n"))
+	assert.Contains(t, ical, "UID:20220101@business-calendar\r\n")
+	assert.Contains(t, ical, "UID:20220102@business-calendar\r\n")
+	assert.NotContains(t, ical, "UID:20220110@business-calendar\r\n") // Рабочий день.
+
+	status, _ := getBody(t, srv.URL+"/api/cal/2023.ics")
+	assert.Equal(t, 404, status)
+
+	// Обычный JSON по-прежнему доступен.
+	status, _ = getBody(t, srv.URL+"/api/cal/2022")
+	assert.Equal(t, 200, status)
+}
+
+func TestServer_ICalFeed(t *testing.T) {
+	y := time.Now().Year()
+
+	st := engine.NewMemory()
+	_ = st.PutYear(y, store.Months{time.January: {1: {Working: false, Type: store.Holiday}}})
+	_ = st.PutYear(y+1, store.Months{time.January: {1: {Working: false, Type: store.Holiday}}})
+	_ = st.PutYear(y+2, store.Months{time.January: {1: {Working: false, Type: store.Holiday}}})
+	generic, _ := source.NewGeneric().GetYear(y - 1)
+	_ = st.PutYear(y-1, generic)
+
+	rest := &Server{Store: st, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	status, ical := getBody(t, srv.URL+"/api/cal.ics")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, ical, fmt.Sprintf("UID:%d0101@business-calendar\r\n", y))
+	assert.Contains(t, ical, fmt.Sprintf("UID:%d0101@business-calendar\r\n", y+1))
+	assert.NotContains(t, ical, fmt.Sprintf("UID:%d0101@business-calendar\r\n", y+2))
+	assert.Equal(t, 2, strings.Count(ical, "BEGIN:VEVENT"))
+
+	// UID событий именованного календаря содержат его код, чтобы не совпадать с событиями других календарей.
+	rest = &Server{Store: st, Opts: testOpts, Calendars: map[string]Store{"kz": st}}
+	srv2 := httptest.NewServer(rest.routes())
+	defer srv2.Close()
+
+	status, ical = getBody(t, srv2.URL+"/api/cal/kz.ics")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, ical, fmt.Sprintf("UID:%d0101.kz@business-calendar\r\n", y))
+	assert.NotContains(t, ical, fmt.Sprintf("UID:%d0101@business-calendar\r\n", y))
+	assert.Contains(t, ical, "X-WR-CALNAME:Производственный календарь (kz)")
+
+	status, ical = getBody(t, fmt.Sprintf("%s/api/cal/kz/%d.ics", srv2.URL, y))
+	assert.Equal(t, 200, status)
+	assert.Contains(t, ical, fmt.Sprintf("UID:%d0101.kz@business-calendar\r\n", y))
+
+	// Основной календарь, доступный и по коду, имеет одинаковые UID событий на обоих путях.
+	rest = &Server{Store: st, Opts: testOpts, Calendars: map[string]Store{"ru": st}, MainCode: "ru"}
+	srv3 := httptest.NewServer(rest.routes())
+	defer srv3.Close()
+
+	for _, path := range []string{"/api/cal.ics", "/api/cal/ru.ics", fmt.Sprintf("/api/cal/%d.ics", y)} {
+		status, ical = getBody(t, srv3.URL+path)
+		assert.Equal(t, 200, status, path)
+		assert.Contains(t, ical, fmt.Sprintf("UID:%d0101.ru@business-calendar\r\n", y), path)
+		assert.NotContains(t, ical, fmt.Sprintf("UID:%d0101@business-calendar\r\n", y), path)
+	}
+}
+
+func TestServer_CSV(t *testing.T) {
+	rest := &Server{Store: testStore, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	expYear := "date,weekDay,working,type,desc,provisional\n" +
+		"2022-01-01,sat,false,holiday,,false\n" +
+		"2022-01-02,sun,false,holiday,,false\n" +
+		"2022-01-10,mon,true,normal,,false\n"
+
+	// Через параметр format.
+	status, csv := getBody(t, srv.URL+"/api/cal/2022?format=csv")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, expYear, csv)
+
+	status, csv = getBody(t, srv.URL+"/api/cal/2022/1?format=csv")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, expYear, csv)
+
+	// Через заголовок Accept.
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/cal/2022/1", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/csv, application/json;q=0.5")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="cal_2022_01.csv"`, resp.Header.Get("Content-Disposition"))
+
+	// Параметр format имеет приоритет.
+	req.URL.RawQuery = "format=json"
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2022?format=xml")
+	assert.Equal(t, 400, status)
+}
+
+func TestResponseFormat(t *testing.T) {
+	tbl := []struct {
+		accept string
+		format string
+	}{
+		{"", formatJson},
+		{"text/csv", formatCSV},
+		{"text/csv, application/json;q=0.5", formatCSV},
+		{"text/csv;q=0.1, application/json", formatJson},
+		{"application/json;q=0.5, text/csv;q=0.5", formatJson},
+		{"text/html, text/csv;q=0.9, */*;q=0.8", formatCSV},
+		{"text/csv;q=0", formatJson},
+		{"text/csv;q=abc", formatJson},
+	}
+
+	for _, tc := range tbl {
+		r := httptest.NewRequest(http.MethodGet, "/api/cal/2022", http.NoBody)
+		r.Header.Set("Accept", tc.accept)
+		f, err := responseFormat(r)
+		assert.NoError(t, err, tc.accept)
+		assert.Equal(t, tc.format, f, tc.accept)
+	}
+}
+
+func TestServer_FlatLayout(t *testing.T) {
+	rest := &Server{Store: testStore, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	expJson := `[
+		{"date": "2022-01-01", "weekDay": "sat", "working": false, "type": "holiday"},
+		{"date": "2022-01-02", "weekDay": "sun", "working": false, "type": "holiday"},
+		{"date": "2022-01-10", "weekDay": "mon", "working": true,  "type": "normal"}
+	]`
+
+	status, respJson := getBody(t, srv.URL+"/api/cal/2022?layout=flat")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, expJson, respJson)
+
+	status, respJson = getBody(t, srv.URL+"/api/cal/2022/1?layout=flat")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, expJson, respJson)
+
+	// Представление по умолчанию не изменилось.
+	status, respJson = getBody(t, srv.URL+"/api/cal/2022/1?layout=nested")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{
+		"1":  {"weekDay": "sat", "working": false, "type": "holiday"},
+		"2":  {"weekDay": "sun", "working": false, "type": "holiday"},
+		"10": {"weekDay": "mon", "working": true,  "type": "normal"}
+	}`, respJson)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2022?layout=foo")
+	assert.Equal(t, 400, status)
+}
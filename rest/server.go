@@ -4,14 +4,17 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/httprate"
+	"github.com/nvkalinin/business-calendar/calendar"
 	"github.com/nvkalinin/business-calendar/log"
 	"github.com/nvkalinin/business-calendar/store"
 	"github.com/nvkalinin/business-calendar/store/engine"
@@ -21,17 +24,25 @@ type Store interface {
 	FindDay(y int, mon time.Month, d int) (*store.Day, bool)
 	FindMonth(y int, mon time.Month) (store.Days, bool)
 	FindYear(y int) (store.Months, bool)
+	FindYearMeta(y int) (*store.YearMeta, bool)
+	FindHistory(y int) ([]store.Revision, bool)
+	FindProvenance(y int) (store.Provenance, bool)
 }
 
 type Updater interface {
-	UpdateCalendar(y int) error
+	// UpdateCalendar обновляет все календари за год y и возвращает изменения каждого из них.
+	UpdateCalendar(y int) ([]calendar.Diff, error)
 }
 
 type Server struct {
-	Store   Store
-	Updater Updater
-	Opts    Opts
-	srv     *http.Server
+	Store     Store            // Основной (федеральный) календарь.
+	Calendars map[string]Store // Именованные календари (например, региональные), доступны по /api/cal/<name>/...
+	MainCode  string           // Код основного календаря (например, ru) для UID событий iCal, пустое — код не указывается.
+	Updater   Updater
+	Events    *Events // Если nil, /api/events недоступен.
+	Opts      Opts
+	srv       *http.Server
+	calName   string // Название именованного календаря, который обслуживает этот Server, пустое — основной календарь.
 }
 
 type Opts struct {
@@ -47,6 +58,9 @@ type Opts struct {
 	RateLimiter bool
 	ReqLimit    int
 	LimitWindow time.Duration
+
+	MaxRangeDays  int // Максимальная длина диапазона /api/cal/range и /api/cal/stats в днях, 0 — без ограничений.
+	MaxBatchDates int // Максимальное количество дат в запросе /api/cal/batch, 0 — без ограничений.
 }
 
 func (s *Server) Run() error {
@@ -69,6 +83,9 @@ func (s *Server) Run() error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.Events != nil {
+		s.Events.Close()
+	}
 	if err := s.srv.Shutdown(ctx); err != nil {
 		return fmt.Errorf("cannot shutdown rest server: %w", err)
 	}
@@ -89,9 +106,19 @@ func (s *Server) routes() *chi.Mux {
 			r.Use(httprate.LimitByIP(s.Opts.ReqLimit, s.Opts.LimitWindow))
 		}
 
-		r.Get("/cal/{y}", s.yearCtrl)
-		r.Get("/cal/{y}/{m}", s.monthCtrl)
-		r.Get("/cal/{y}/{m}/{d}", s.dayCtrl)
+		s.calRoutes(r, "/cal")
+
+		// Именованные календари обслуживаются теми же обработчиками, но со своим хранилищем.
+		// Статические пути /cal/<name> имеют приоритет над /cal/{y}.
+		for name, st := range s.Calendars {
+			cal := &Server{Store: st, Opts: s.Opts, calName: name}
+			cal.calRoutes(r, "/cal/"+name)
+		}
+		r.Get("/cal/{cal:[a-z][a-z0-9_-]*}/*", unknownCalendarCtrl)
+
+		if s.Events != nil {
+			r.Get("/events", s.eventsCtrl)
+		}
 
 		r.Route("/admin", func(r chi.Router) {
 			r.Use(middleware.BasicAuth("business-calendar", map[string]string{"admin": s.Opts.AdminPasswd}))
@@ -105,6 +132,45 @@ func (s *Server) routes() *chi.Mux {
 	return r
 }
 
+// calRoutes регистрирует обработчики календаря s.Store с префиксом prefix.
+func (s *Server) calRoutes(r chi.Router, prefix string) {
+	r.Get(prefix+"/add", s.addWorkDaysCtrl)
+	r.Get(prefix+"/stats", s.statsCtrl)
+	r.Get(prefix+"/range", s.rangeCtrl)
+	r.Post(prefix+"/batch", s.batchCtrl)
+	r.Get(prefix+"/next", s.nextWorkDayCtrl)
+	r.Get(prefix+"/prev", s.prevWorkDayCtrl)
+	r.Get(prefix+".ics", s.icalFeedCtrl)
+	r.Get(prefix+"/{y}", s.yearCtrl)
+	r.Get(prefix+"/{y}.ics", s.yearICalCtrl)
+	r.Get(prefix+"/{y}/norms", s.yearNormsCtrl)
+	r.Get(prefix+"/{y}/history", s.historyCtrl)
+	r.Get(prefix+"/{y}/status", s.statusCtrl)
+	r.Get(prefix+"/{y}/{m}", s.monthCtrl)
+	r.Get(prefix+"/{y}/{m}/norms", s.monthNormsCtrl)
+	r.Get(prefix+"/{y}/{m}/{d}", s.dayCtrl)
+}
+
+// calNamePattern — допустимые названия именованных календарей. Название не может начинаться с цифры,
+// иначе путь /cal/<name> перекрыл бы /cal/{y}.
+var calNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// reservedCalNames совпадают со статическими путями из calRoutes.
+var reservedCalNames = map[string]bool{
+	"add": true, "stats": true, "range": true, "batch": true, "next": true, "prev": true,
+}
+
+// CheckCalendarName проверяет, что name можно использовать как название именованного календаря в Server.Calendars.
+func CheckCalendarName(name string) error {
+	if !calNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid calendar name '%s', it must match %s", name, calNamePattern)
+	}
+	if reservedCalNames[name] {
+		return fmt.Errorf("calendar name '%s' is reserved", name)
+	}
+	return nil
+}
+
 func (s *Server) yearCtrl(w http.ResponseWriter, r *http.Request) {
 	y, err := yearParam(r)
 	if err != nil {
@@ -112,13 +178,63 @@ func (s *Server) yearCtrl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Has("asOf") {
+		s.yearAsOf(w, r, y)
+		return
+	}
+
+	if s.notModified(w, r, y) {
+		return
+	}
+
 	year, found := s.Store.FindYear(y)
 	if !found {
 		sendErrorJson(w, 404, "year not found")
 		return
 	}
 
-	sendJsonResponse(w, year)
+	sendDays(w, r, fmt.Sprintf("cal_%d", y), year, year.DateDays(y))
+}
+
+// yearAsOf возвращает календарь года в том виде, в котором он был сохранен на момент asOf.
+func (s *Server) yearAsOf(w http.ResponseWriter, r *http.Request, y int) {
+	asOf, err := time.Parse(time.RFC3339, r.URL.Query().Get("asOf"))
+	if err != nil {
+		sendErrorJson(w, 400, "invalid 'asOf', expected RFC 3339 timestamp (2022-01-02T15:04:05Z)")
+		return
+	}
+
+	history, _ := s.Store.FindHistory(y)
+	rev, found := store.RevisionAt(history, asOf)
+	if !found {
+		sendErrorJson(w, 404, "year not found")
+		return
+	}
+
+	w.Header().Set("X-Revision-Time", rev.Time.Format(time.RFC3339Nano))
+	setStatusHeader(w, rev.Status)
+	sendDays(w, r, fmt.Sprintf("cal_%d_%s", y, rev.Time.Format("20060102T150405")), rev.Months, rev.Months.DateDays(y))
+}
+
+// historyCtrl возвращает список версий года (без содержимого) в порядке сохранения.
+func (s *Server) historyCtrl(w http.ResponseWriter, r *http.Request) {
+	y, err := yearParam(r)
+	if err != nil {
+		sendErrorJson(w, 400, "invalid year")
+		return
+	}
+
+	history, found := s.Store.FindHistory(y)
+	if !found {
+		sendErrorJson(w, 404, "year not found")
+		return
+	}
+
+	for i := range history {
+		history[i].Months = nil
+		history[i].Provenance = nil
+	}
+	sendJsonResponse(w, history)
 }
 
 func (s *Server) monthCtrl(w http.ResponseWriter, r *http.Request) {
@@ -130,13 +246,31 @@ func (s *Server) monthCtrl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	explain, err := explainParam(r)
+	if err != nil {
+		sendErrorJson(w, 400, err.Error())
+		return
+	}
+
+	// Источники дней могут измениться без изменения содержимого года, поэтому ответы с explain не кешируются.
+	if explain {
+		s.sendStatusHeader(w, y)
+	} else if s.notModified(w, r, y) {
+		return
+	}
+
 	month, found := s.Store.FindMonth(y, m)
 	if !found {
 		sendErrorJson(w, 404, "month not found")
 		return
 	}
 
-	sendJsonResponse(w, month)
+	if explain {
+		s.sendExplainedDays(w, r, y, m, month)
+		return
+	}
+
+	sendDays(w, r, fmt.Sprintf("cal_%d_%02d", y, m), month, month.DateDays(y, m))
 }
 
 func (s *Server) dayCtrl(w http.ResponseWriter, r *http.Request) {
@@ -149,15 +283,261 @@ func (s *Server) dayCtrl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	explain, err := explainParam(r)
+	if err != nil {
+		sendErrorJson(w, 400, err.Error())
+		return
+	}
+
+	if explain {
+		s.sendStatusHeader(w, y)
+	} else if s.notModified(w, r, y) {
+		return
+	}
+
 	day, found := s.Store.FindDay(y, m, d)
 	if !found {
 		sendErrorJson(w, 404, "date not found")
 		return
 	}
 
+	if explain {
+		prov, _ := s.Store.FindProvenance(y)
+		sendJsonResponse(w, explainDay(*day, prov, m, d))
+		return
+	}
+
+	sendJsonResponse(w, day)
+}
+
+// notModified проверяет условный запрос к данным года y (см. checkNotModified) и задает заголовок X-Calendar-Status.
+// Для годов, сохраненных без метаданных, условные запросы не поддерживаются.
+func (s *Server) notModified(w http.ResponseWriter, r *http.Request, y int) bool {
+	meta, found := s.Store.FindYearMeta(y)
+	if !found {
+		return false
+	}
+	setStatusHeader(w, meta.Status)
+	return checkNotModified(w, r, meta)
+}
+
+func (s *Server) yearNormsCtrl(w http.ResponseWriter, r *http.Request) {
+	y, err := yearParam(r)
+	if err != nil {
+		sendErrorJson(w, 400, "invalid year")
+		return
+	}
+
+	year, found := s.Store.FindYear(y)
+	if !found {
+		sendErrorJson(w, 404, "year not found")
+		return
+	}
+
+	sendJsonResponse(w, calendar.NewYearNorms(year))
+}
+
+func (s *Server) monthNormsCtrl(w http.ResponseWriter, r *http.Request) {
+	y, err1 := yearParam(r)
+	m, err2 := monthParam(r)
+	err := combineErrors(err1, err2)
+	if err != nil {
+		sendErrorJson(w, 400, "invalid date")
+		return
+	}
+
+	month, found := s.Store.FindMonth(y, m)
+	if !found {
+		sendErrorJson(w, 404, "month not found")
+		return
+	}
+
+	sendJsonResponse(w, calendar.MonthNorm(month))
+}
+
+// addWorkDaysCtrl прибавляет к дате date указанное количество рабочих дней days (может быть отрицательным).
+func (s *Server) addWorkDaysCtrl(w http.ResponseWriter, r *http.Request) {
+	date, err1 := dateQuery(r, "date")
+	n, err2 := intQuery(r, "days")
+	err := combineErrors(err1, err2)
+	if err != nil {
+		sendErrorJson(w, 400, fmt.Sprintf("invalid params: %v", err))
+		return
+	}
+
+	day, err := calendar.AddWorkDays(s.Store, date, n)
+	if err != nil {
+		sendCalcError(w, err)
+		return
+	}
+
 	sendJsonResponse(w, day)
 }
 
+// nextWorkDayCtrl возвращает n-й рабочий день, начиная с даты date включительно (по умолчанию n=1).
+func (s *Server) nextWorkDayCtrl(w http.ResponseWriter, r *http.Request) {
+	s.nearestWorkDay(w, r, calendar.NextWorkDay)
+}
+
+// prevWorkDayCtrl возвращает n-й рабочий день, начиная с даты date включительно, в обратном направлении.
+func (s *Server) prevWorkDayCtrl(w http.ResponseWriter, r *http.Request) {
+	s.nearestWorkDay(w, r, calendar.PrevWorkDay)
+}
+
+func (s *Server) nearestWorkDay(
+	w http.ResponseWriter, r *http.Request,
+	find func(f calendar.Finder, date time.Time, n int) (store.DateDay, error),
+) {
+	date, err := dateQuery(r, "date")
+	if err != nil {
+		sendErrorJson(w, 400, fmt.Sprintf("invalid params: %v", err))
+		return
+	}
+
+	n := 1
+	if r.URL.Query().Has("n") {
+		n, err = intQuery(r, "n")
+		if err == nil && n < 1 {
+			err = fmt.Errorf("'n' must be positive")
+		}
+		if err != nil {
+			sendErrorJson(w, 400, fmt.Sprintf("invalid params: %v", err))
+			return
+		}
+	}
+
+	day, err := find(s.Store, date, n)
+	if err != nil {
+		sendCalcError(w, err)
+		return
+	}
+
+	sendJsonResponse(w, day)
+}
+
+// rangeCtrl возвращает все дни в диапазоне [from, to], который может охватывать несколько лет.
+func (s *Server) rangeCtrl(w http.ResponseWriter, r *http.Request) {
+	from, to, err := rangeQuery(r)
+	if err != nil {
+		sendErrorJson(w, 400, fmt.Sprintf("invalid params: %v", err))
+		return
+	}
+
+	if !s.checkRangeLen(w, from, to) {
+		return
+	}
+
+	days, err := calendar.Range(s.Store, from, to)
+	if err != nil {
+		sendCalcError(w, err)
+		return
+	}
+
+	// В представлении по умолчанию дни группируются по годам: год -> месяц -> день.
+	nested := make(map[int]store.Months, to.Year()-from.Year()+1)
+	for _, d := range days {
+		y, mon := d.Date.Year(), d.Date.Month()
+		if nested[y] == nil {
+			nested[y] = make(store.Months, 12)
+		}
+		if nested[y][mon] == nil {
+			nested[y][mon] = make(store.Days, 31)
+		}
+		nested[y][mon][d.Date.Day()] = d.Day
+	}
+
+	fileName := fmt.Sprintf("cal_%s_%s", from.Format(store.DateLayout), to.Format(store.DateLayout))
+	sendDays(w, r, fileName, nested, days)
+}
+
+// checkRangeLen проверяет, что диапазон [from, to] не длиннее Opts.MaxRangeDays, иначе отправляет ошибку 400.
+func (s *Server) checkRangeLen(w http.ResponseWriter, from, to time.Time) bool {
+	numDays := int(to.Sub(from).Hours()/24) + 1
+	if s.Opts.MaxRangeDays > 0 && numDays > s.Opts.MaxRangeDays {
+		sendErrorJson(w, 400, fmt.Sprintf("range is too long: %d days, max %d", numDays, s.Opts.MaxRangeDays))
+		return false
+	}
+	return true
+}
+
+type batchReq struct {
+	Dates []string `json:"dates"`
+}
+
+type batchItem struct {
+	Date  string     `json:"date"`
+	Found bool       `json:"found"`
+	Day   *store.Day `json:"day,omitempty"`
+}
+
+// batchCtrl возвращает дни по списку дат из тела запроса. Даты могут относиться к разным годам.
+// Ответ — массив в том же порядке, что и даты в запросе; если даты нет в календаре, found=false.
+func (s *Server) batchCtrl(w http.ResponseWriter, r *http.Request) {
+	if s.Opts.MaxBatchDates > 0 {
+		// Примерно 20 байт на одну дату в JSON с запасом на пробелы и переносы строк.
+		r.Body = http.MaxBytesReader(w, r.Body, int64(s.Opts.MaxBatchDates)*20+1024)
+	}
+
+	var req batchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorJson(w, 400, fmt.Sprintf("cannot parse request: %v", err))
+		return
+	}
+
+	if s.Opts.MaxBatchDates > 0 && len(req.Dates) > s.Opts.MaxBatchDates {
+		sendErrorJson(w, 400, fmt.Sprintf("too many dates: %d, max %d", len(req.Dates), s.Opts.MaxBatchDates))
+		return
+	}
+
+	dates := make([]time.Time, len(req.Dates))
+	for i, v := range req.Dates {
+		date, err := store.ParseDate(v)
+		if err != nil {
+			sendErrorJson(w, 400, fmt.Sprintf("invalid date '%s' at index %d, expected YYYY-MM-DD", v, i))
+			return
+		}
+		dates[i] = date
+	}
+
+	days := calendar.FindDays(s.Store, dates)
+
+	res := make([]batchItem, len(dates))
+	for i, day := range days {
+		res[i] = batchItem{
+			Date:  dates[i].Format(store.DateLayout),
+			Found: day != nil,
+			Day:   day,
+		}
+	}
+
+	sendJsonResponse(w, res)
+}
+
+// statsCtrl считает количество рабочих, выходных и праздничных дней в диапазоне [from, to].
+func (s *Server) statsCtrl(w http.ResponseWriter, r *http.Request) {
+	from, to, err := rangeQuery(r)
+	if err != nil {
+		sendErrorJson(w, 400, fmt.Sprintf("invalid params: %v", err))
+		return
+	}
+
+	if !s.checkRangeLen(w, from, to) {
+		return
+	}
+
+	st, err := calendar.RangeStats(s.Store, from, to)
+	if err != nil {
+		sendCalcError(w, err)
+		return
+	}
+
+	sendJsonResponse(w, st)
+}
+
+func unknownCalendarCtrl(w http.ResponseWriter, r *http.Request) {
+	sendErrorJson(w, 404, fmt.Sprintf("calendar not found: %s", chi.URLParam(r, "cal")))
+}
+
 func pingCtrl(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(200)
 }
@@ -203,6 +583,46 @@ func dayParam(r *http.Request) (int, error) {
 	return d, nil
 }
 
+func intQuery(r *http.Request, param string) (int, error) {
+	strVal := r.URL.Query().Get(param)
+	if strVal == "" {
+		return 0, fmt.Errorf("'%s' param is required", param)
+	}
+
+	v, err := strconv.Atoi(strVal)
+	if err != nil {
+		return 0, fmt.Errorf("invalid '%s': %w", param, err)
+	}
+	return v, nil
+}
+
+func dateQuery(r *http.Request, param string) (time.Time, error) {
+	strVal := r.URL.Query().Get(param)
+	if strVal == "" {
+		return time.Time{}, fmt.Errorf("'%s' param is required", param)
+	}
+
+	date, err := store.ParseDate(strVal)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid '%s', expected YYYY-MM-DD", param)
+	}
+	return date, nil
+}
+
+// rangeQuery читает диапазон дат из параметров from и to, обе даты включаются в диапазон.
+func rangeQuery(r *http.Request) (from, to time.Time, err error) {
+	from, err1 := dateQuery(r, "from")
+	to, err2 := dateQuery(r, "to")
+	if err = combineErrors(err1, err2); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("'to' must not be before 'from'")
+	}
+	return from, to, nil
+}
+
 func (s *Server) backupCtrl(w http.ResponseWriter, r *http.Request) {
 	// Поддерживается только резервное копирование bolt.
 	// Для поддержки бекапа произвольных хранилищ нужно сделать экспорт/импорт через отдельный формат.
@@ -232,6 +652,12 @@ func (s *Server) backupCtrl(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// SyncResult — результат синхронизации одного года в ответе /api/admin/sync.
+type SyncResult struct {
+	Status string          `json:"status"` // ok или текст ошибки.
+	Diffs  []calendar.Diff `json:"diffs"`  // Изменения календарей, которые удалось обновить.
+}
+
 func (s *Server) syncCtrl(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
 		sendErrorJson(w, 400, "cannot parse request")
@@ -256,14 +682,14 @@ func (s *Server) syncCtrl(w http.ResponseWriter, r *http.Request) {
 	}
 	log.Printf("[DEBUG] requested years to sync (after parsing): %v", years)
 
-	res := make(map[int]string)
+	res := make(map[int]SyncResult)
 	for _, y := range years {
 		log.Printf("[INFO] syncing year %d...", y)
-		err := s.Updater.UpdateCalendar(y)
+		diffs, err := s.Updater.UpdateCalendar(y)
 		if err != nil {
-			res[y] = fmt.Sprintf("error: %v", err)
+			res[y] = SyncResult{Status: fmt.Sprintf("error: %v", err), Diffs: diffs}
 		} else {
-			res[y] = "ok"
+			res[y] = SyncResult{Status: "ok", Diffs: diffs}
 		}
 	}
 	log.Printf("[DEBUG] sync result: %+v", res)
@@ -301,7 +727,24 @@ func sendJsonResponse(w http.ResponseWriter, data interface{}) {
 	}
 }
 
+// sendCalcError отправляет ответ с ошибкой, которую вернули вычисления над датами из пакета calendar.
+func sendCalcError(w http.ResponseWriter, err error) {
+	var missingErr *calendar.MissingYearsError
+	switch {
+	case errors.As(err, &missingErr), errors.Is(err, calendar.ErrDateNotFound):
+		sendErrorJson(w, 404, err.Error())
+	default:
+		log.Printf("[WARN] calendar calculation error: %+v", err)
+		sendErrorJson(w, 500, "calculation error")
+	}
+}
+
 func sendErrorJson(w http.ResponseWriter, status int, msg string) {
+	// Заголовки для кеширования могли быть заданы до ошибки (см. checkNotModified), но к ошибке не относятся.
+	w.Header().Del("ETag")
+	w.Header().Del("Last-Modified")
+	w.Header().Del("Cache-Control")
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
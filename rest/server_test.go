@@ -1,12 +1,16 @@
 package rest
 
 import (
+	"github.com/nvkalinin/business-calendar/calendar"
+	"github.com/nvkalinin/business-calendar/source"
 	"github.com/nvkalinin/business-calendar/store"
 	"github.com/nvkalinin/business-calendar/store/engine"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -109,3 +113,363 @@ func TestServer_Day(t *testing.T) {
 	defer resp.Body.Close()
 	assert.Equal(t, 404, resp.StatusCode)
 }
+
+func TestServer_AddWorkDays(t *testing.T) {
+	st := engine.NewMemory()
+	y2021, _ := source.NewGeneric().GetYear(2021)
+	_ = st.PutYear(2021, y2021)
+
+	rest := &Server{Store: st, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	// Нормальный случай: пятница + 1 рабочий день.
+	status, respJson := getBody(t, srv.URL+"/api/cal/add?date=2021-12-24&days=1")
+	assert.Equal(t, 200, status)
+	expJson := `{"date": "2021-12-27", "weekDay": "mon", "working": true, "type": "normal", "provisional": true}`
+	assert.JSONEq(t, expJson, respJson)
+
+	status, respJson = getBody(t, srv.URL+"/api/cal/add?date=2021-12-27&days=-1")
+	assert.Equal(t, 200, status)
+	expJson = `{"date": "2021-12-24", "weekDay": "fri", "working": true, "type": "normal", "provisional": true}`
+	assert.JSONEq(t, expJson, respJson)
+
+	// 2022 год не синхронизирован.
+	status, respJson = getBody(t, srv.URL+"/api/cal/add?date=2021-12-30&days=2")
+	assert.Equal(t, 404, status)
+	assert.Contains(t, respJson, "2022")
+
+	// Неверные параметры.
+	status, _ = getBody(t, srv.URL+"/api/cal/add?date=2021-12-32&days=2")
+	assert.Equal(t, 400, status)
+	status, _ = getBody(t, srv.URL+"/api/cal/add?date=2021-12-30")
+	assert.Equal(t, 400, status)
+}
+
+func TestServer_NextPrevWorkDay(t *testing.T) {
+	st := engine.NewMemory()
+	y2021, _ := source.NewGeneric().GetYear(2021)
+	_ = st.PutYear(2021, y2021)
+
+	rest := &Server{Store: st, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	// 25.12.2021 — суббота.
+	status, respJson := getBody(t, srv.URL+"/api/cal/next?date=2021-12-25")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"date": "2021-12-27", "weekDay": "mon", "working": true, "type": "normal", "provisional": true}`, respJson)
+
+	status, respJson = getBody(t, srv.URL+"/api/cal/next?date=2021-12-25&n=2")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"date": "2021-12-28", "weekDay": "tue", "working": true, "type": "normal", "provisional": true}`, respJson)
+
+	status, respJson = getBody(t, srv.URL+"/api/cal/prev?date=2021-12-25")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"date": "2021-12-24", "weekDay": "fri", "working": true, "type": "normal", "provisional": true}`, respJson)
+
+	// Следующий рабочий день в 2022 году, который не синхронизирован.
+	status, _ = getBody(t, srv.URL+"/api/cal/next?date=2021-12-31&n=2")
+	assert.Equal(t, 404, status)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/next?date=2021-12-25&n=0")
+	assert.Equal(t, 400, status)
+}
+
+func TestServer_Range(t *testing.T) {
+	st := engine.NewMemory()
+	y2021, _ := source.NewGeneric().GetYear(2021)
+	y2022, _ := source.NewGeneric().GetYear(2022)
+	_ = st.PutYear(2021, y2021)
+	_ = st.PutYear(2022, y2022)
+
+	opts := testOpts
+	opts.MaxRangeDays = 366
+	rest := &Server{Store: st, Opts: opts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	status, respJson := getBody(t, srv.URL+"/api/cal/range?from=2021-12-31&to=2022-01-01")
+	assert.Equal(t, 200, status)
+	expJson := `{
+		"2021": {"12": {"31": {"weekDay": "fri", "working": true,  "type": "normal", "provisional": true}}},
+		"2022": {"1":  {"1":  {"weekDay": "sat", "working": false, "type": "weekend", "provisional": true}}}
+	}`
+	assert.JSONEq(t, expJson, respJson)
+
+	status, respJson = getBody(t, srv.URL+"/api/cal/range?from=2021-12-31&to=2022-01-01&layout=flat")
+	assert.Equal(t, 200, status)
+	expJson = `[
+		{"date": "2021-12-31", "weekDay": "fri", "working": true,  "type": "normal", "provisional": true},
+		{"date": "2022-01-01", "weekDay": "sat", "working": false, "type": "weekend", "provisional": true}
+	]`
+	assert.JSONEq(t, expJson, respJson)
+
+	// Отсутствующие годы перечисляются в ответе.
+	status, respJson = getBody(t, srv.URL+"/api/cal/range?from=2020-12-31&to=2021-01-01")
+	assert.Equal(t, 404, status)
+	assert.Contains(t, respJson, "2020")
+
+	// Слишком длинный диапазон.
+	status, _ = getBody(t, srv.URL+"/api/cal/range?from=2021-01-01&to=2022-01-01")
+	assert.Equal(t, 200, status)
+	status, _ = getBody(t, srv.URL+"/api/cal/range?from=2021-01-01&to=2022-01-02")
+	assert.Equal(t, 400, status)
+}
+
+func TestServer_Batch(t *testing.T) {
+	opts := testOpts
+	opts.MaxBatchDates = 3
+	rest := &Server{Store: testStore, Opts: opts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	body := `{"dates": ["2022-01-10", "2023-01-01", "2022-01-01"]}`
+	resp, err := http.Post(srv.URL+"/api/cal/batch", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+
+	respJson, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	expJson := `[
+		{"date": "2022-01-10", "found": true,  "day": {"weekDay": "mon", "working": true, "type": "normal"}},
+		{"date": "2023-01-01", "found": false},
+		{"date": "2022-01-01", "found": true,  "day": {"weekDay": "sat", "working": false, "type": "holiday"}}
+	]`
+	assert.JSONEq(t, expJson, string(respJson))
+
+	// Неверная дата.
+	body = `{"dates": ["2022-01-10", "2022-13-01"]}`
+	resp, err = http.Post(srv.URL+"/api/cal/batch", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 400, resp.StatusCode)
+
+	// Слишком много дат.
+	body = `{"dates": ["2022-01-10", "2022-01-11", "2022-01-12", "2022-01-13"]}`
+	resp, err = http.Post(srv.URL+"/api/cal/batch", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestServer_Stats(t *testing.T) {
+	st := engine.NewMemory()
+	y2021, _ := source.NewGeneric().GetYear(2021)
+	y2022, _ := source.NewGeneric().GetYear(2022)
+	_ = st.PutYear(2021, y2021)
+	_ = st.PutYear(2022, y2022)
+
+	opts := testOpts
+	opts.MaxRangeDays = 1830
+	rest := &Server{Store: st, Opts: opts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	status, respJson := getBody(t, srv.URL+"/api/cal/stats?from=2021-12-27&to=2022-01-09")
+	assert.Equal(t, 200, status)
+	expJson := `{
+		"calendarDays": 14,
+		"working":      10,
+		"nonWorking":   4,
+		"normal":       10,
+		"weekend":      4,
+		"holiday":      0,
+		"preHoliday":   0,
+		"noWork":       0,
+		"workHours":    80
+	}`
+	assert.JSONEq(t, expJson, respJson)
+
+	status, respJson = getBody(t, srv.URL+"/api/cal/stats?from=2020-12-01&to=2023-01-01")
+	assert.Equal(t, 404, status)
+	assert.Contains(t, respJson, "2020, 2023")
+
+	status, _ = getBody(t, srv.URL+"/api/cal/stats?from=2022-01-09&to=2021-12-27")
+	assert.Equal(t, 400, status)
+
+	// Слишком длинный диапазон.
+	status, respJson = getBody(t, srv.URL+"/api/cal/stats?from=0001-01-01&to=9999-12-31")
+	assert.Equal(t, 400, status)
+	assert.Contains(t, respJson, "range is too long")
+}
+
+func TestServer_Norms(t *testing.T) {
+	rest := &Server{Store: testStore, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	// В testStore только три дня января.
+	status, respJson := getBody(t, srv.URL+"/api/cal/2022/1/norms")
+	assert.Equal(t, 200, status)
+	expJson := `{
+		"calendarDays":   3,
+		"workingDays":    1,
+		"nonWorkingDays": 2,
+		"hours40":        8,
+		"hours36":        7.2,
+		"hours24":        4.8
+	}`
+	assert.JSONEq(t, expJson, respJson)
+
+	status, respJson = getBody(t, srv.URL+"/api/cal/2022/norms")
+	assert.Equal(t, 200, status)
+	expJson = `{
+		"year":     {"calendarDays": 3, "workingDays": 1, "nonWorkingDays": 2, "hours40": 8, "hours36": 7.2, "hours24": 4.8},
+		"halves":   {"1": {"calendarDays": 3, "workingDays": 1, "nonWorkingDays": 2, "hours40": 8, "hours36": 7.2, "hours24": 4.8}},
+		"quarters": {"1": {"calendarDays": 3, "workingDays": 1, "nonWorkingDays": 2, "hours40": 8, "hours36": 7.2, "hours24": 4.8}},
+		"months":   {"1": {"calendarDays": 3, "workingDays": 1, "nonWorkingDays": 2, "hours40": 8, "hours36": 7.2, "hours24": 4.8}}
+	}`
+	assert.JSONEq(t, expJson, respJson)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2022/2/norms")
+	assert.Equal(t, 404, status)
+	status, _ = getBody(t, srv.URL+"/api/cal/2023/norms")
+	assert.Equal(t, 404, status)
+}
+
+func TestServer_Calendars(t *testing.T) {
+	regStore := engine.NewMemory()
+	_ = regStore.PutYear(2022, store.Months{
+		time.August: {
+			30: store.Day{WeekDay: store.Tuesday, Working: false, Type: store.Holiday, Desc: "День Республики"},
+			31: store.Day{WeekDay: store.Wednesday, Working: true, Type: store.Normal},
+		},
+	})
+
+	rest := &Server{
+		Store:     testStore,
+		Calendars: map[string]Store{"tatarstan": regStore},
+		Opts:      testOpts,
+	}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	status, body := getBody(t, srv.URL+"/api/cal/tatarstan/2022/8/30")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"weekDay": "tue", "working": false, "type": "holiday", "desc": "День Республики"}`, body)
+
+	status, body = getBody(t, srv.URL+"/api/cal/tatarstan/next?date=2022-08-30")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"date": "2022-08-31", "weekDay": "wed", "working": true, "type": "normal"}`, body)
+
+	status, body = getBody(t, srv.URL+"/api/cal/tatarstan.ics")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, "X-WR-CALNAME:Производственный календарь (tatarstan")
+
+	// Основной календарь не изменился.
+	status, _ = getBody(t, srv.URL+"/api/cal/2022/1/1")
+	assert.Equal(t, 200, status)
+	status, _ = getBody(t, srv.URL+"/api/cal/2022/8/30")
+	assert.Equal(t, 404, status)
+	status, _ = getBody(t, srv.URL+"/api/cal/tatarstan/2022/1/1")
+	assert.Equal(t, 404, status)
+
+	status, body = getBody(t, srv.URL+"/api/cal/bashkortostan/2022")
+	assert.Equal(t, 404, status)
+	assert.Contains(t, body, "calendar not found")
+}
+
+func TestServer_History(t *testing.T) {
+	v1 := store.Months{time.May: {2: {WeekDay: store.Monday, Working: true, Type: store.Normal}}}
+	v2 := store.Months{time.May: {2: {WeekDay: store.Monday, Working: false, Type: store.Holiday}}}
+	t1 := time.Date(2022, time.January, 10, 12, 0, 0, 0, time.UTC)
+	t2 := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	st := engine.NewMemory()
+	_ = st.PutRevision(2022, store.Revision{Time: t1, Sources: []string{"generic"}, Hash: v1.Hash(), Months: v1})
+	_ = st.PutRevision(2022, store.Revision{Time: t2, Sources: []string{"generic", "consultant"}, Hash: v2.Hash(), Months: v2})
+
+	rest := &Server{Store: st, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	status, body := getBody(t, srv.URL+"/api/cal/2022/history")
+	assert.Equal(t, 200, status)
+	expJson := `[
+		{"time": "2022-01-10T12:00:00Z", "sources": ["generic"], "hash": "` + v1.Hash() + `"},
+		{"time": "2022-03-01T12:00:00Z", "sources": ["generic", "consultant"], "hash": "` + v2.Hash() + `"}
+	]`
+	assert.JSONEq(t, expJson, body)
+
+	status, body = getBody(t, srv.URL+"/api/cal/2022?asOf=2022-02-01T00:00:00%2B03:00")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"5": {"2": {"weekDay": "mon", "working": true, "type": "normal"}}}`, body)
+
+	status, body = getBody(t, srv.URL+"/api/cal/2022?asOf=2022-03-01T12:00:00Z")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"5": {"2": {"weekDay": "mon", "working": false, "type": "holiday"}}}`, body)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2022?asOf=2022-01-01T00:00:00Z")
+	assert.Equal(t, 404, status)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2022?asOf=2022-01-01")
+	assert.Equal(t, 400, status)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2023/history")
+	assert.Equal(t, 404, status)
+}
+
+type updaterMock struct {
+	diffs []calendar.Diff
+	err   error
+}
+
+func (u *updaterMock) UpdateCalendar(y int) ([]calendar.Diff, error) {
+	return u.diffs, u.err
+}
+
+func TestServer_Sync(t *testing.T) {
+	upd := &updaterMock{diffs: []calendar.Diff{{
+		Year: 2022,
+		Changes: []calendar.DayChange{{
+			Date: "2022-05-02",
+			Kind: calendar.DayChanged,
+			Old:  &store.Day{WeekDay: store.Monday, Working: true, Type: store.Normal},
+			New:  &store.Day{WeekDay: store.Monday, Working: false, Type: store.Holiday},
+		}},
+	}}}
+
+	rest := &Server{Store: testStore, Updater: upd, Opts: testOpts}
+	rest.Opts.AdminPasswd = "pass"
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/admin/sync?y=2022", http.NoBody)
+	require.NoError(t, err)
+	req.SetBasicAuth("admin", "pass")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	expJson := `{"2022": {"status": "ok", "diffs": [{"year": 2022, "changes": [{
+		"date": "2022-05-02",
+		"kind": "changed",
+		"old": {"weekDay": "mon", "working": true, "type": "normal"},
+		"new": {"weekDay": "mon", "working": false, "type": "holiday"}
+	}]}]}}`
+	assert.JSONEq(t, expJson, string(body))
+}
+
+func TestCheckCalendarName(t *testing.T) {
+	assert.NoError(t, CheckCalendarName("tatarstan"))
+	assert.NoError(t, CheckCalendarName("ru-ta"))
+	assert.Error(t, CheckCalendarName(""))
+	assert.Error(t, CheckCalendarName("2022"))
+	assert.Error(t, CheckCalendarName("Tatarstan"))
+	assert.Error(t, CheckCalendarName("a/b"))
+	assert.Error(t, CheckCalendarName("range"))
+}
+
+func getBody(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
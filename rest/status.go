@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// statusHeader — заголовок ответов с днями года, в котором передается статус года (store.YearStatus).
+const statusHeader = "X-Calendar-Status"
+
+// yearStatus — ответ /{y}/status.
+type yearStatus struct {
+	Year            int              `json:"year"`
+	Status          store.YearStatus `json:"status"`
+	ProvisionalDays int              `json:"provisionalDays"`
+	Modified        *time.Time       `json:"modified,omitempty"` // Нет, если год сохранен без метаданных.
+}
+
+// sendStatusHeader задает заголовок X-Calendar-Status по метаданным года y.
+// Для годов, сохраненных без метаданных, заголовок не задается.
+func (s *Server) sendStatusHeader(w http.ResponseWriter, y int) {
+	if meta, found := s.Store.FindYearMeta(y); found {
+		setStatusHeader(w, meta.Status)
+	}
+}
+
+// setStatusHeader задает заголовок X-Calendar-Status. Пустой статус (год или версия сохранены до появления
+// статусов) передается как store.StatusUnknown.
+func setStatusHeader(w http.ResponseWriter, status store.YearStatus) {
+	w.Header().Set(statusHeader, string(knownStatus(status)))
+}
+
+// knownStatus возвращает status или store.StatusUnknown, если статус не сохранен.
+// Статус не вычисляется по дням: в данных, сохраненных до появления статусов, нет отметок о предварительных днях.
+func knownStatus(status store.YearStatus) store.YearStatus {
+	if status == "" {
+		return store.StatusUnknown
+	}
+	return status
+}
+
+// statusCtrl возвращает статус года: подтвержден ли календарь источниками или сгенерирован по правилам.
+func (s *Server) statusCtrl(w http.ResponseWriter, r *http.Request) {
+	y, err := yearParam(r)
+	if err != nil {
+		sendErrorJson(w, 400, "invalid year")
+		return
+	}
+
+	year, found := s.Store.FindYear(y)
+	if !found {
+		sendErrorJson(w, 404, "year not found")
+		return
+	}
+
+	res := yearStatus{Year: y, Status: store.StatusUnknown}
+	for _, days := range year {
+		for _, day := range days {
+			if day.Provisional {
+				res.ProvisionalDays++
+			}
+		}
+	}
+	if meta, found := s.Store.FindYearMeta(y); found {
+		res.Status = knownStatus(meta.Status)
+		res.Modified = &meta.Modified
+	}
+
+	setStatusHeader(w, res.Status)
+	sendJsonResponse(w, res)
+}
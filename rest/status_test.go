@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/nvkalinin/business-calendar/store/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_status(t *testing.T) {
+	st := engine.NewMemory()
+	require.NoError(t, st.PutYear(2022, store.Months{time.January: {
+		1: {WeekDay: store.Saturday, Working: false, Type: store.Holiday},
+	}}))
+	require.NoError(t, st.PutYear(2023, store.Months{time.January: {
+		1: {WeekDay: store.Sunday, Working: false, Type: store.Holiday, Provisional: true},
+		9: {WeekDay: store.Monday, Working: true, Type: store.Normal},
+	}}))
+	require.NoError(t, st.PutYear(2024, store.Months{time.January: {
+		1: {WeekDay: store.Monday, Working: false, Type: store.Holiday, Provisional: true},
+	}}))
+
+	rest := &Server{Store: st, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	exp := map[string]string{
+		"/api/cal/2022":             "confirmed",
+		"/api/cal/2023/1":           "partial",
+		"/api/cal/2023/1?explain=1": "partial",
+		"/api/cal/2024/1/1":         "generated",
+		"/api/cal/2024?format=csv":  "generated",
+	}
+	for path, status := range exp {
+		resp := doGet(t, srv.URL+path, nil)
+		assert.Equal(t, 200, resp.StatusCode, path)
+		assert.Equal(t, status, resp.Header.Get("X-Calendar-Status"), path)
+	}
+
+	status, body := getBody(t, srv.URL+"/api/cal/2023/status")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"modified":`)
+	assert.Contains(t, body, `"year":2023,"status":"partial","provisionalDays":1`)
+
+	status, _ = getBody(t, srv.URL+"/api/cal/2025/status")
+	assert.Equal(t, 404, status)
+}
+
+// legacyStore возвращает метаданные и версии годов без статуса, как у годов, сохраненных до появления статусов.
+type legacyStore struct {
+	*engine.Memory
+}
+
+func (s legacyStore) FindYearMeta(y int) (*store.YearMeta, bool) {
+	meta, ok := s.Memory.FindYearMeta(y)
+	if ok {
+		meta.Status = ""
+	}
+	return meta, ok
+}
+
+func (s legacyStore) FindHistory(y int) ([]store.Revision, bool) {
+	h, ok := s.Memory.FindHistory(y)
+	for i := range h {
+		h[i].Status = ""
+	}
+	return h, ok
+}
+
+func TestServer_status_unknown(t *testing.T) {
+	// Дни года, сгенерированного до появления статусов, не отмечены как предварительные, поэтому статус
+	// не вычисляется по дням.
+	st := legacyStore{engine.NewMemory()}
+	require.NoError(t, st.PutYear(2022, store.Months{time.January: {
+		1: {WeekDay: store.Saturday, Working: false, Type: store.Holiday},
+	}}))
+
+	rest := &Server{Store: st, Opts: testOpts}
+	srv := httptest.NewServer(rest.routes())
+	defer srv.Close()
+
+	for _, path := range []string{"/api/cal/2022", "/api/cal/2022/1/1", "/api/cal/2022?asOf=2100-01-01T00:00:00Z"} {
+		resp := doGet(t, srv.URL+path, nil)
+		assert.Equal(t, 200, resp.StatusCode, path)
+		assert.Equal(t, "unknown", resp.Header.Get("X-Calendar-Status"), path)
+	}
+
+	status, body := getBody(t, srv.URL+"/api/cal/2022/status")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"year":2022,"status":"unknown","provisionalDays":0`)
+}
@@ -1,14 +1,20 @@
 package source
 
 import (
-	"github.com/nvkalinin/business-calendar/store"
+	"fmt"
+	"sort"
 	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
 )
 
 // Generic генерирует календарь на год, в котором
 // дни недели Weekend являются выходными, остальные — рабочие.
+// Если заданы Holidays (см. Rules), то они отмечаются как праздники.
+// Все дни отмечаются как предварительные (store.Day.Provisional): их должны подтвердить другие источники.
 type Generic struct {
-	Weekend []time.Weekday
+	Weekend  []time.Weekday
+	Holidays []HolidayRule
 }
 
 func NewGeneric() *Generic {
@@ -17,6 +23,10 @@ func NewGeneric() *Generic {
 	}
 }
 
+func (*Generic) Name() string {
+	return "generic"
+}
+
 func (g *Generic) GetYear(targetYear int) (store.Months, error) {
 	cal := makeEmptyYear()
 
@@ -34,17 +44,68 @@ func (g *Generic) GetYear(targetYear int) (store.Months, error) {
 		weekDay, _ := store.NewWeekDay(date.Weekday())
 
 		cal[month][day] = store.Day{
-			WeekDay: weekDay,
-			Working: !isWeekend,
-			Type:    dayType,
+			WeekDay:     weekDay,
+			Working:     !isWeekend,
+			Type:        dayType,
+			Provisional: true,
 		}
 
 		date = date.AddDate(0, 0, 1)
 	}
 
+	g.addHolidays(cal, targetYear)
 	return cal, nil
 }
 
+// addHolidays отмечает праздники года y. Сначала отмечаются все праздники, затем выполняются переносы,
+// чтобы выходной не был перенесен на другой праздник.
+func (g *Generic) addHolidays(cal store.Months, y int) {
+	type holiday struct {
+		date time.Time
+		rule HolidayRule
+	}
+
+	holidays := make([]holiday, 0, len(g.Holidays))
+	for _, rule := range g.Holidays {
+		date, ok := rule.dateIn(y)
+		if !ok || date.Year() != y {
+			continue
+		}
+		holidays = append(holidays, holiday{date, rule})
+	}
+	sort.SliceStable(holidays, func(i, j int) bool {
+		return holidays[i].date.Before(holidays[j].date)
+	})
+
+	for _, h := range holidays {
+		day := cal[h.date.Month()][h.date.Day()]
+		day.Working = false
+		day.Type = store.Holiday
+		day.Desc = h.rule.Desc
+		cal[h.date.Month()][h.date.Day()] = day
+	}
+
+	for _, h := range holidays {
+		if !h.rule.Transfer || !g.isWeekend(h.date.Weekday()) {
+			continue
+		}
+
+		// Выходной переносится на ближайший рабочий день того же года.
+		for date := h.date.AddDate(0, 0, 1); date.Year() == y; date = date.AddDate(0, 0, 1) {
+			day := cal[date.Month()][date.Day()]
+			if !day.Working {
+				continue
+			}
+
+			day.Working = false
+			day.Type = store.Weekend
+			day.Desc = fmt.Sprintf("Перенос выходного дня с %s (%s)", h.date.Format("02.01"), h.rule.Desc)
+			cal[date.Month()][date.Day()] = day
+			break
+		}
+	}
+}
+
 func makeEmptyYear() store.Months {
 	cal := make(store.Months, 12)
 	for mon := 1; mon <= 12; mon++ {
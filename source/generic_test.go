@@ -12,79 +12,79 @@ func TestGeneric_GetYear(t *testing.T) {
 	year, _ := generic.GetYear(2022)
 
 	expJanuary := store.Days{
-		1: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		2: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		1: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		2: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		3: store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
-		4: store.Day{WeekDay: "tue", Working: true, Type: store.Normal},
-		5: store.Day{WeekDay: "wed", Working: true, Type: store.Normal},
-		6: store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		7: store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		8: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		9: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		3: store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
+		4: store.Day{WeekDay: "tue", Working: true, Type: store.Normal, Provisional: true},
+		5: store.Day{WeekDay: "wed", Working: true, Type: store.Normal, Provisional: true},
+		6: store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		7: store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		8: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		9: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		10: store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
-		11: store.Day{WeekDay: "tue", Working: true, Type: store.Normal},
-		12: store.Day{WeekDay: "wed", Working: true, Type: store.Normal},
-		13: store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		14: store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		15: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		16: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		10: store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
+		11: store.Day{WeekDay: "tue", Working: true, Type: store.Normal, Provisional: true},
+		12: store.Day{WeekDay: "wed", Working: true, Type: store.Normal, Provisional: true},
+		13: store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		14: store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		15: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		16: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		17: store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
-		18: store.Day{WeekDay: "tue", Working: true, Type: store.Normal},
-		19: store.Day{WeekDay: "wed", Working: true, Type: store.Normal},
-		20: store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		21: store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		22: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		23: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		17: store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
+		18: store.Day{WeekDay: "tue", Working: true, Type: store.Normal, Provisional: true},
+		19: store.Day{WeekDay: "wed", Working: true, Type: store.Normal, Provisional: true},
+		20: store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		21: store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		22: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		23: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		24: store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
-		25: store.Day{WeekDay: "tue", Working: true, Type: store.Normal},
-		26: store.Day{WeekDay: "wed", Working: true, Type: store.Normal},
-		27: store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		28: store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		29: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		30: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		24: store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
+		25: store.Day{WeekDay: "tue", Working: true, Type: store.Normal, Provisional: true},
+		26: store.Day{WeekDay: "wed", Working: true, Type: store.Normal, Provisional: true},
+		27: store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		28: store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		29: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		30: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		31: store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
+		31: store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
 	}
 	expDecember := store.Days{
-		1: store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		2: store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		3: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		4: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		1: store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		2: store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		3: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		4: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		5:  store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
-		6:  store.Day{WeekDay: "tue", Working: true, Type: store.Normal},
-		7:  store.Day{WeekDay: "wed", Working: true, Type: store.Normal},
-		8:  store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		9:  store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		10: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		11: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		5:  store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
+		6:  store.Day{WeekDay: "tue", Working: true, Type: store.Normal, Provisional: true},
+		7:  store.Day{WeekDay: "wed", Working: true, Type: store.Normal, Provisional: true},
+		8:  store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		9:  store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		10: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		11: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		12: store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
-		13: store.Day{WeekDay: "tue", Working: true, Type: store.Normal},
-		14: store.Day{WeekDay: "wed", Working: true, Type: store.Normal},
-		15: store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		16: store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		17: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		18: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		12: store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
+		13: store.Day{WeekDay: "tue", Working: true, Type: store.Normal, Provisional: true},
+		14: store.Day{WeekDay: "wed", Working: true, Type: store.Normal, Provisional: true},
+		15: store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		16: store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		17: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		18: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		19: store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
-		20: store.Day{WeekDay: "tue", Working: true, Type: store.Normal},
-		21: store.Day{WeekDay: "wed", Working: true, Type: store.Normal},
-		22: store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		23: store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		24: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
-		25: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend},
+		19: store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
+		20: store.Day{WeekDay: "tue", Working: true, Type: store.Normal, Provisional: true},
+		21: store.Day{WeekDay: "wed", Working: true, Type: store.Normal, Provisional: true},
+		22: store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		23: store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		24: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
+		25: store.Day{WeekDay: "sun", Working: false, Type: store.Weekend, Provisional: true},
 
-		26: store.Day{WeekDay: "mon", Working: true, Type: store.Normal},
-		27: store.Day{WeekDay: "tue", Working: true, Type: store.Normal},
-		28: store.Day{WeekDay: "wed", Working: true, Type: store.Normal},
-		29: store.Day{WeekDay: "thu", Working: true, Type: store.Normal},
-		30: store.Day{WeekDay: "fri", Working: true, Type: store.Normal},
-		31: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend},
+		26: store.Day{WeekDay: "mon", Working: true, Type: store.Normal, Provisional: true},
+		27: store.Day{WeekDay: "tue", Working: true, Type: store.Normal, Provisional: true},
+		28: store.Day{WeekDay: "wed", Working: true, Type: store.Normal, Provisional: true},
+		29: store.Day{WeekDay: "thu", Working: true, Type: store.Normal, Provisional: true},
+		30: store.Day{WeekDay: "fri", Working: true, Type: store.Normal, Provisional: true},
+		31: store.Day{WeekDay: "sat", Working: false, Type: store.Weekend, Provisional: true},
 	}
 
 	assert.Equal(t, 12, len(year))
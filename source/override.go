@@ -15,6 +15,10 @@ type Override struct {
 
 type overrides map[int]store.Months // Ключ - год.
 
+func (o *Override) Name() string {
+	return "override:" + o.Path
+}
+
 func (o *Override) GetYear(y int) (store.Months, error) {
 	// Админ может менять файл, поэтому читаем его при каждом вызове.
 	f, err := os.ReadFile(o.Path)
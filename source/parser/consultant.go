@@ -17,6 +17,15 @@ type Consultant struct {
 	baseURL   string // Только для тестирования.
 }
 
+func (*Consultant) Name() string {
+	return "consultant"
+}
+
+// External реализует calendar.External: данные загружаются с сайта и проверяются перед сохранением.
+func (*Consultant) External() bool {
+	return true
+}
+
 func (c *Consultant) GetYear(y int) (store.Months, error) {
 	dom, err := c.getCalendarPage(y)
 	if err != nil {
@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/log"
+	"github.com/nvkalinin/business-calendar/source"
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// Decree читает текст постановления Правительства РФ "О переносе выходных дней" из локальных файлов
+// (текст или HTML, например, скопированный из PDF или с сайта).
+//
+// Из постановления берутся переносы вида "с субботы 5 марта на понедельник 7 марта": день, на который перенесен
+// выходной, становится нерабочим, а день, с которого он перенесен, — рабочим, если это не праздничный день.
+// Тип дня при переносе не задается, чтобы сохранился тип из других источников (например, сокращенный
+// предпраздничный день у парсера). Сами праздники в постановлении не перечисляются, они берутся из встроенных
+// правил ru (ст. 112 ТК РФ) для года, за который есть переносы. Сокращенные дни должны дать другие источники.
+//
+// Год переносов берется из фразы "в 2022 году" или указывается у даты ("1 января 2023 г.").
+// GetYear возвращает праздники и переносы за год из всех файлов Files, и ошибку, если переносов за год нет.
+type Decree struct {
+	Files []string
+}
+
+// Transfer — перенос выходного дня с From на To.
+type Transfer struct {
+	From time.Time
+	To   time.Time
+}
+
+var (
+	decreeMonths = map[string]time.Month{
+		"января": time.January, "февраля": time.February, "марта": time.March, "апреля": time.April,
+		"мая": time.May, "июня": time.June, "июля": time.July, "августа": time.August,
+		"сентября": time.September, "октября": time.October, "ноября": time.November, "декабря": time.December,
+	}
+
+	// Дни недели в родительном ("с субботы") и винительном ("на субботу") падежах.
+	decreeWeekdays = map[string]time.Weekday{
+		"понедельника": time.Monday, "вторника": time.Tuesday, "среды": time.Wednesday, "четверга": time.Thursday,
+		"пятницы": time.Friday, "субботы": time.Saturday, "воскресенья": time.Sunday,
+		"понедельник": time.Monday, "вторник": time.Tuesday, "среду": time.Wednesday, "четверг": time.Thursday,
+		"пятницу": time.Friday, "субботу": time.Saturday, "воскресенье": time.Sunday,
+	}
+
+	decreeDate     = `(?:([а-я]+)\s+)?(\d{1,2})\s+([а-я]+)(?:\s+(\d{4})(?:\s*(?:г\.|года?))?)?`
+	decreeTransfer = regexp.MustCompile(`(?:^|[^а-я])с\s+` + decreeDate + `\s+на\s+` + decreeDate)
+	decreeYear     = regexp.MustCompile(`в\s+(\d{4})\s+году`)
+	decreeTags     = regexp.MustCompile(`<[^>]*>`)
+)
+
+func (*Decree) Name() string {
+	return "decree"
+}
+
+func (d *Decree) GetYear(y int) (store.Months, error) {
+	rules, err := source.LoadRules("ru")
+	if err != nil {
+		return nil, fmt.Errorf("parser/decree %w", err)
+	}
+
+	// Если выходной переносится с праздника или на праздник, праздник остается нерабочим.
+	months := rules.HolidaysIn(y)
+	holidays := months.Copy()
+	found := false
+
+	for _, path := range d.Files {
+		transfers, err := ParseDecreeFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tr := range transfers {
+			if tr.From.Year() == y {
+				if !isHoliday(holidays, tr.From) {
+					setDecreeDay(months, tr.From, store.Day{
+						Working: true,
+						Desc:    fmt.Sprintf("Рабочий день, выходной перенесен на %s", tr.To.Format("02.01")),
+					})
+				}
+				found = true
+			}
+			if tr.To.Year() == y {
+				if !isHoliday(holidays, tr.To) {
+					setDecreeDay(months, tr.To, store.Day{
+						Working: false,
+						Desc:    fmt.Sprintf("Перенос выходного дня с %s", tr.From.Format("02.01")),
+					})
+				}
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("parser/decree no transfers for year %d", y)
+	}
+	return months, nil
+}
+
+// ParseDecreeFile читает переносы выходных дней из файла с текстом постановления.
+func ParseDecreeFile(path string) ([]Transfer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("parser/decree cannot read file: %w", err)
+	}
+
+	transfers, err := ParseDecree(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parser/decree %s: %w", path, err)
+	}
+	log.Printf("[DEBUG] parser/decree %s: %d transfer(s)", path, len(transfers))
+	return transfers, nil
+}
+
+// ParseDecree находит в тексте постановления переносы выходных дней.
+func ParseDecree(text string) ([]Transfer, error) {
+	text = html.UnescapeString(decreeTags.ReplaceAllString(text, " "))
+	text = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+
+	year := 0
+	if m := decreeYear.FindStringSubmatch(text); m != nil {
+		year, _ = strconv.Atoi(m[1])
+	}
+
+	var transfers []Transfer
+	for _, m := range decreeTransfer.FindAllStringSubmatch(text, -1) {
+		from, err := parseDecreeDate(m[1], m[2], m[3], m[4], year)
+		if err != nil {
+			return nil, fmt.Errorf("transfer '%s': %w", m[0], err)
+		}
+		to, err := parseDecreeDate(m[5], m[6], m[7], m[8], year)
+		if err != nil {
+			return nil, fmt.Errorf("transfer '%s': %w", m[0], err)
+		}
+
+		transfers = append(transfers, Transfer{From: from, To: to})
+	}
+
+	if len(transfers) == 0 {
+		return nil, fmt.Errorf("no transfers found")
+	}
+	return transfers, nil
+}
+
+func parseDecreeDate(weekday, day, month, year string, defaultYear int) (time.Time, error) {
+	mon, ok := decreeMonths[month]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown month '%s'", month)
+	}
+
+	y := defaultYear
+	if year != "" {
+		y, _ = strconv.Atoi(year)
+	}
+	if y == 0 {
+		return time.Time{}, fmt.Errorf("year is not specified")
+	}
+
+	d, _ := strconv.Atoi(day)
+	if d < 1 || d > daysInMonth(y, mon) {
+		return time.Time{}, fmt.Errorf("invalid date %s %s %d", day, month, y)
+	}
+	date := store.NewDate(y, mon, d)
+
+	// День недели в тексте необязателен, но если он указан, он должен совпадать с датой.
+	if weekday != "" {
+		wd, ok := decreeWeekdays[weekday]
+		if !ok {
+			return time.Time{}, fmt.Errorf("unknown weekday '%s'", weekday)
+		}
+		if wd != date.Weekday() {
+			return time.Time{}, fmt.Errorf("%s is %s, not %s", date.Format(store.DateLayout), date.Weekday(), weekday)
+		}
+	}
+
+	return date, nil
+}
+
+func isHoliday(holidays store.Months, date time.Time) bool {
+	_, ok := holidays[date.Month()][date.Day()]
+	return ok
+}
+
+func setDecreeDay(months store.Months, date time.Time, day store.Day) {
+	if months[date.Month()] == nil {
+		months[date.Month()] = make(store.Days)
+	}
+	day.WeekDay, _ = store.NewWeekDay(date.Weekday())
+	months[date.Month()][date.Day()] = day
+}
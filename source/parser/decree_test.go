@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"github.com/nvkalinin/business-calendar/calendar"
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDecree_GetYear(t *testing.T) {
+	decree := &Decree{Files: []string{"testdata/decree_2022.txt", "testdata/decree_2023.html"}}
+
+	year, err := decree.GetYear(2022)
+	require.NoError(t, err)
+
+	// @formatter:off
+	expTransfers := store.Months{
+		time.March: {
+			5: {WeekDay: store.Saturday, Working: true,  Desc: "Рабочий день, выходной перенесен на 07.03"},
+			7: {WeekDay: store.Monday,   Working: false, Desc: "Перенос выходного дня с 05.03"},
+		},
+		time.May: {
+			3:  {WeekDay: store.Tuesday, Working: false, Desc: "Перенос выходного дня с 01.01"},
+			10: {WeekDay: store.Tuesday, Working: false, Desc: "Перенос выходного дня с 02.01"},
+		},
+	}
+	// @formatter:on
+	for mon, days := range expTransfers {
+		for d, exp := range days {
+			assert.Equal(t, exp, year[mon][d], "2022-%02d-%02d", mon, d)
+		}
+	}
+
+	// Праздники берутся из правил ru, January 1 and 2 are holidays and must not become working days.
+	assert.Equal(t, store.Day{WeekDay: store.Saturday, Working: false, Type: store.Holiday, Desc: "Новогодние каникулы"},
+		year[time.January][1])
+	assert.Equal(t, store.Holiday, year[time.January][2].Type)
+	assert.Equal(t, store.Holiday, year[time.March][8].Type)
+	assert.Equal(t, store.Holiday, year[time.November][4].Type)
+	assert.Len(t, year[time.January], 8)
+	assert.Len(t, year[time.May], 4)
+
+	year, err = decree.GetYear(2023)
+	require.NoError(t, err)
+	assert.Equal(t, store.Day{WeekDay: store.Friday, Working: false, Desc: "Перенос выходного дня с 01.01"}, year[time.February][24])
+	assert.Equal(t, store.Day{WeekDay: store.Monday, Working: false, Desc: "Перенос выходного дня с 08.01"}, year[time.May][8])
+	assert.Equal(t, store.Holiday, year[time.February][23].Type)
+
+	_, err = decree.GetYear(2024)
+	assert.ErrorContains(t, err, "no transfers for year 2024")
+
+	_, err = (&Decree{Files: []string{"testdata/decree_1999.txt"}}).GetYear(1999)
+	assert.ErrorContains(t, err, "cannot read file")
+}
+
+func TestDecree_processor(t *testing.T) {
+	// Постановление применяется после парсера: переносы меняют только Working и описание, а тип дня остается
+	// от парсера, например, сокращенная предпраздничная суббота 5 марта 2022 года.
+	p := calendar.NewProcessor(calendar.ProcOpts{Src: []calendar.Source{
+		&XMLCalendar{File: "testdata/xmlcalendar_2022.xml"},
+		&Decree{Files: []string{"testdata/decree_2022.txt"}},
+	}})
+	year := p.MakeCalendar(2022)
+
+	assert.Equal(t, store.Day{WeekDay: store.Saturday, Working: true, Type: store.PreHoliday,
+		Desc: "Рабочий день, выходной перенесен на 07.03"}, year[time.March][5])
+	assert.Equal(t, store.Day{WeekDay: store.Monday, Working: false, Type: store.Weekend,
+		Desc: "Перенос выходного дня с 05.03"}, year[time.March][7])
+	assert.Equal(t, store.Holiday, year[time.January][1].Type)
+	assert.Equal(t, store.Holiday, year[time.May][9].Type)
+
+	// Норма как в опубликованном календаре: 22 рабочих дня, 175 часов.
+	norm := calendar.MonthNorm(year[time.March])
+	assert.Equal(t, 22, norm.WorkingDays)
+	assert.Equal(t, 175.0, norm.Hours40)
+}
+
+func TestParseDecree(t *testing.T) {
+	transfers, err := ParseDecree("Перенести выходной день с субботы 2 января 2016 г. на вторник 3 мая 2016 года.")
+	require.NoError(t, err)
+	assert.Equal(t, []Transfer{{From: store.NewDate(2016, time.January, 2), To: store.NewDate(2016, time.May, 3)}}, transfers)
+
+	transfers, err = ParseDecree("В 2021 году: с 20 февраля на 22 февраля")
+	require.NoError(t, err)
+	assert.Equal(t, []Transfer{{From: store.NewDate(2021, time.February, 20), To: store.NewDate(2021, time.February, 22)}}, transfers)
+
+	tbl := map[string]string{
+		"Перенести в 2022 году с пятницы 1 января на вторник 3 мая": "2022-01-01 is Saturday, not пятницы",
+		"Перенести в 2022 году с субботы 32 января на вторник 3 мая": "invalid date 32 января 2022",
+		"Перенести с субботы 1 января на вторник 3 мая":               "year is not specified",
+		"Перенести в 2022 году выходные дни":                          "no transfers found",
+	}
+	for text, expErr := range tbl {
+		_, err := ParseDecree(text)
+		assert.ErrorContains(t, err, expErr, text)
+	}
+}
@@ -18,6 +18,15 @@ type SuperJob struct {
 	baseURL   string // Только для тестирования.
 }
 
+func (*SuperJob) Name() string {
+	return "superjob"
+}
+
+// External реализует calendar.External: данные загружаются с сайта и проверяются перед сохранением.
+func (*SuperJob) External() bool {
+	return true
+}
+
 func (s *SuperJob) GetYear(y int) (store.Months, error) {
 	dom, err := s.getCalendarPage(y)
 	if err != nil {
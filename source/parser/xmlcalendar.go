@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/log"
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// XMLCalendarURL — адрес открытых данных xmlcalendar.ru по умолчанию.
+const XMLCalendarURL = "https://xmlcalendar.ru/data/ru/{year}/calendar.xml"
+
+// XMLCalendar читает календарь в формате xmlcalendar.ru по URL или из локального файла.
+//
+// В формате перечислены только дни, которые отличаются от обычной пятидневки:
+//
+//	<day d="MM.DD" t="тип" h="id праздника" f="MM.DD"/>
+//
+// t=1 — выходной (праздник, если указан h; перенесенный выходной, если указан f — дата, с которой он перенесен),
+// t=2 — сокращенный рабочий день, t=3 — рабочий день (перенесенный на субботу или воскресенье).
+// Остальные дни года заполняются по обычной пятидневке, поэтому GetYear всегда возвращает все дни года.
+type XMLCalendar struct {
+	Client    *http.Client
+	UserAgent string
+	URL       string // Адрес файла, {year} заменяется на год. Если пуст, используется XMLCalendarURL.
+	File      string // Путь к локальному файлу, {year} заменяется на год. Если задан, URL не используется.
+}
+
+type xmlCalendar struct {
+	Year     int          `xml:"year,attr"`
+	Holidays []xmlHoliday `xml:"holidays>holiday"`
+	Days     []xmlDay     `xml:"days>day"`
+}
+
+type xmlHoliday struct {
+	ID    int    `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+}
+
+type xmlDay struct {
+	Date    string `xml:"d,attr"`
+	Type    int    `xml:"t,attr"`
+	Holiday int    `xml:"h,attr"`
+	From    string `xml:"f,attr"`
+}
+
+func (*XMLCalendar) Name() string {
+	return "xmlcalendar"
+}
+
+// External реализует calendar.External: данные загружаются извне и проверяются перед сохранением.
+func (*XMLCalendar) External() bool {
+	return true
+}
+
+func (x *XMLCalendar) GetYear(y int) (store.Months, error) {
+	data, err := x.read(y)
+	if err != nil {
+		return nil, err
+	}
+
+	var cal xmlCalendar
+	if err := xml.Unmarshal(data, &cal); err != nil {
+		return nil, fmt.Errorf("parser/xmlcalendar cannot parse xml: %w", err)
+	}
+	if cal.Year != y {
+		return nil, fmt.Errorf("parser/xmlcalendar expected year %d, got %d", y, cal.Year)
+	}
+
+	return x.makeYear(y, cal)
+}
+
+// read возвращает содержимое файла за год y из локального файла или по URL.
+func (x *XMLCalendar) read(y int) ([]byte, error) {
+	if x.File != "" {
+		path := withYear(x.File, y)
+		log.Printf("[DEBUG] parser/xmlcalendar year %d reading file %s", y, path)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("parser/xmlcalendar cannot read file: %w", err)
+		}
+		return data, nil
+	}
+
+	url := x.URL
+	if url == "" {
+		url = XMLCalendarURL
+	}
+	url = withYear(url, y)
+
+	req, _ := http.NewRequest("GET", url, nil)
+	if x.UserAgent != "" {
+		req.Header.Set("User-Agent", x.UserAgent)
+	}
+	log.Printf("[DEBUG] parser/xmlcalendar year %d request: URL=%s", y, url)
+
+	client := x.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("parser/xmlcalendar cannot GET calendar: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("[WARN] parser/xmlcalendar cannot close response: %+v", err)
+		}
+	}()
+	log.Printf("[DEBUG] parser/xmlcalendar year %d response: status=%d", y, resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("parser/xmlcalendar cannot GET calendar: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parser/xmlcalendar cannot read response: %w", err)
+	}
+	return data, nil
+}
+
+func (x *XMLCalendar) makeYear(y int, cal xmlCalendar) (store.Months, error) {
+	months := make(store.Months, 12)
+	for m := time.January; m <= time.December; m++ {
+		days := make(store.Days, 31)
+		for d := 1; d <= daysInMonth(y, m); d++ {
+			wd := weekdayOf(y, m, d)
+			day := store.Day{Working: true, Type: store.Normal}
+			day.WeekDay, _ = store.NewWeekDay(wd)
+			if wd == time.Saturday || wd == time.Sunday {
+				day.Working = false
+				day.Type = store.Weekend
+			}
+			days[d] = day
+		}
+		months[m] = days
+	}
+
+	titles := make(map[int]string, len(cal.Holidays))
+	for _, h := range cal.Holidays {
+		titles[h.ID] = strings.TrimSpace(h.Title)
+	}
+
+	for _, xd := range cal.Days {
+		m, d, err := parseXMLDate(y, xd.Date)
+		if err != nil {
+			return nil, err
+		}
+		day := months[m][d]
+
+		switch xd.Type {
+		case 1:
+			day.Working = false
+			switch {
+			case xd.Holiday != 0:
+				day.Type = store.Holiday
+				day.Desc = titles[xd.Holiday]
+			case xd.From != "":
+				fromMon, fromDay, err := parseXMLDate(y, xd.From)
+				if err != nil {
+					return nil, err
+				}
+				day.Type = store.Weekend
+				day.Desc = fmt.Sprintf("Перенос выходного дня с %02d.%02d", fromDay, fromMon)
+			default:
+				day.Type = store.Weekend
+			}
+		case 2:
+			day.Working = true
+			day.Type = store.PreHoliday
+		case 3:
+			day.Working = true
+			day.Type = store.Normal
+		default:
+			return nil, fmt.Errorf("parser/xmlcalendar unknown type %d of day %s", xd.Type, xd.Date)
+		}
+
+		months[m][d] = day
+	}
+
+	return months, nil
+}
+
+// parseXMLDate разбирает дату в формате MM.DD.
+func parseXMLDate(y int, s string) (time.Month, int, error) {
+	monStr, dayStr, ok := strings.Cut(s, ".")
+	mon, err1 := strconv.Atoi(monStr)
+	day, err2 := strconv.Atoi(dayStr)
+	if !ok || err1 != nil || err2 != nil || mon < 1 || mon > 12 || day < 1 || day > daysInMonth(y, time.Month(mon)) {
+		return 0, 0, fmt.Errorf("parser/xmlcalendar invalid date '%s', expected MM.DD", s)
+	}
+	return time.Month(mon), day, nil
+}
+
+func withYear(tmpl string, y int) string {
+	return strings.ReplaceAll(tmpl, "{year}", strconv.Itoa(y))
+}
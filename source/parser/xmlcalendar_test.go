@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestXMLCalendar_GetYear(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/data/ru/2022/calendar.xml", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test", r.Header.Get("User-Agent"))
+
+		xml, err := ioutil.ReadFile("testdata/xmlcalendar_2022.xml")
+		require.NoError(t, err)
+
+		_, _ = w.Write(xml)
+	})
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	xmlCal := &XMLCalendar{
+		Client:    s.Client(),
+		UserAgent: "test",
+		URL:       s.URL + "/data/ru/{year}/calendar.xml",
+	}
+
+	year, err := xmlCal.GetYear(2022)
+	require.NoError(t, err)
+	assertXMLCalendar2022(t, year)
+
+	_, err = xmlCal.GetYear(2023)
+	assert.ErrorContains(t, err, "status 404")
+}
+
+func TestXMLCalendar_GetYear_file(t *testing.T) {
+	xmlCal := &XMLCalendar{File: "testdata/xmlcalendar_{year}.xml"}
+
+	year, err := xmlCal.GetYear(2022)
+	require.NoError(t, err)
+	assertXMLCalendar2022(t, year)
+
+	_, err = xmlCal.GetYear(2023)
+	assert.ErrorContains(t, err, "cannot read file")
+
+	// Файл без шаблона года содержит данные только за свой год.
+	xmlCal = &XMLCalendar{File: "testdata/xmlcalendar_2022.xml"}
+	_, err = xmlCal.GetYear(2021)
+	assert.ErrorContains(t, err, "expected year 2021, got 2022")
+}
+
+func TestXMLCalendar_GetYear_invalid(t *testing.T) {
+	dir := t.TempDir()
+	tbl := map[string]string{
+		"cannot parse xml":     `<calendar year="2022"><days>`,
+		"invalid date '2.x'":   `<calendar year="2022"><days><day d="2.x" t="1"/></days></calendar>`,
+		"invalid date '02.30'": `<calendar year="2022"><days><day d="02.30" t="1"/></days></calendar>`,
+		"unknown type 5":       `<calendar year="2022"><days><day d="02.01" t="5"/></days></calendar>`,
+	}
+
+	for expErr, data := range tbl {
+		path := filepath.Join(dir, "calendar.xml")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+		_, err := (&XMLCalendar{File: path}).GetYear(2022)
+		assert.ErrorContains(t, err, expErr)
+	}
+}
+
+func assertXMLCalendar2022(t *testing.T, year store.Months) {
+	assert.Len(t, year, 12)
+	for m := time.January; m <= time.December; m++ {
+		assert.Len(t, year[m], daysInMonth(2022, m))
+	}
+
+	// @formatter:off
+	expMay := store.Days{
+		1:  {WeekDay: store.Sunday,    Working: false, Type: store.Holiday, Desc: "Праздник Весны и Труда"},
+		2:  {WeekDay: store.Monday,    Working: false, Type: store.Weekend, Desc: "Перенос выходного дня с 01.05"},
+		3:  {WeekDay: store.Tuesday,   Working: false, Type: store.Weekend, Desc: "Перенос выходного дня с 01.01"},
+		4:  {WeekDay: store.Wednesday, Working: true,  Type: store.Normal},
+		5:  {WeekDay: store.Thursday,  Working: true,  Type: store.Normal},
+		6:  {WeekDay: store.Friday,    Working: true,  Type: store.Normal},
+		7:  {WeekDay: store.Saturday,  Working: false, Type: store.Weekend},
+		8:  {WeekDay: store.Sunday,    Working: false, Type: store.Weekend},
+		9:  {WeekDay: store.Monday,    Working: false, Type: store.Holiday, Desc: "День Победы"},
+		10: {WeekDay: store.Tuesday,   Working: false, Type: store.Weekend, Desc: "Перенос выходного дня с 02.01"},
+		11: {WeekDay: store.Wednesday, Working: true,  Type: store.Normal},
+	}
+	// @formatter:on
+	for d, exp := range expMay {
+		assert.Equal(t, exp, year[time.May][d], "2022-05-%02d", d)
+	}
+
+	assert.Equal(t, store.Day{WeekDay: store.Saturday, Working: true, Type: store.PreHoliday}, year[time.March][5])
+	assert.Equal(t, store.Day{WeekDay: store.Monday, Working: false, Type: store.Weekend,
+		Desc: "Перенос выходного дня с 05.03"}, year[time.March][7])
+	assert.Equal(t, "Новогодние каникулы (в ред. Федерального закона от 23.04.2012 № 35-ФЗ)", year[time.January][1].Desc)
+	assert.Equal(t, "Рождество Христово", year[time.January][7].Desc)
+
+	working := 0
+	for _, days := range year {
+		for _, day := range days {
+			if day.Working {
+				working++
+			}
+		}
+	}
+	assert.Equal(t, 247, working)
+}
@@ -0,0 +1,229 @@
+package source
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/log"
+	"github.com/nvkalinin/business-calendar/store"
+	"gopkg.in/yaml.v3"
+)
+
+// rulesFS содержит встроенные правила календарей стран, по одному YAML-файлу на код страны (ISO 3166-1 alpha-2).
+//
+//go:embed rules/*.yml
+var rulesFS embed.FS
+
+// Rules — правила производственного календаря страны: выходные дни недели и праздники.
+type Rules struct {
+	Name     string          `yaml:"name"`
+	Weekend  []store.WeekDay `yaml:"weekend"`
+	Holidays []HolidayRule   `yaml:"holidays"`
+}
+
+// Пасхалии для праздников, дата которых отсчитывается от Пасхи.
+const (
+	EasterOrthodox = "orthodox"
+	EasterWestern  = "western"
+)
+
+// HolidayRule описывает праздник. Дата задается одним из способов:
+//   - Date — одна и та же дата каждый год;
+//   - Easter и Offset — через Offset дней после Пасхи (Offset может быть отрицательным);
+//   - Dates — явно по годам, для праздников по лунному календарю, которые объявляются ежегодно.
+type HolidayRule struct {
+	Desc   string         `yaml:"desc"`
+	Date   string         `yaml:"date"` // ММ-ДД.
+	Easter string         `yaml:"easter"`
+	Offset int            `yaml:"offset"`
+	Dates  map[int]string `yaml:"dates"` // Год -> ММ-ДД.
+
+	// Годы, в которые действует праздник (включительно), 0 — без ограничения.
+	From int `yaml:"from"`
+	To   int `yaml:"to"`
+
+	// Если праздник выпадает на выходной, выходной переносится на следующий рабочий день.
+	Transfer bool `yaml:"transfer"`
+}
+
+// Countries возвращает коды стран, для которых есть встроенные правила.
+func Countries() []string {
+	entries, _ := fs.ReadDir(rulesFS, "rules")
+
+	codes := make([]string, 0, len(entries))
+	for _, e := range entries {
+		codes = append(codes, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// LoadRules загружает встроенные правила страны по коду (например, kz).
+func LoadRules(country string) (*Rules, error) {
+	f, err := rulesFS.ReadFile("rules/" + country + ".yml")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("unknown country '%s', available: %s", country, strings.Join(Countries(), ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read rules of '%s': %w", country, err)
+	}
+
+	r := &Rules{}
+	if err := yaml.Unmarshal(f, r); err != nil {
+		return nil, fmt.Errorf("cannot parse rules of '%s': %w", country, err)
+	}
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("invalid rules of '%s': %w", country, err)
+	}
+	return r, nil
+}
+
+func (r *Rules) validate() error {
+	if len(r.Weekend) == 0 {
+		return fmt.Errorf("weekend days are not set")
+	}
+	for _, wd := range r.Weekend {
+		if _, ok := wd.Weekday(); !ok {
+			return fmt.Errorf("invalid weekend day '%s'", wd)
+		}
+	}
+
+	for i, h := range r.Holidays {
+		if err := h.validate(); err != nil {
+			return fmt.Errorf("holiday %d (%s): %w", i, h.Desc, err)
+		}
+	}
+	return nil
+}
+
+func (h *HolidayRule) validate() error {
+	kinds := 0
+	if h.Date != "" {
+		kinds++
+		if _, err := parseMonthDay(2000, h.Date); err != nil {
+			return err
+		}
+	}
+	if h.Easter != "" {
+		kinds++
+		if h.Easter != EasterOrthodox && h.Easter != EasterWestern {
+			return fmt.Errorf("unknown easter '%s'", h.Easter)
+		}
+	}
+	if len(h.Dates) > 0 {
+		kinds++
+		for y, md := range h.Dates {
+			if _, err := parseMonthDay(y, md); err != nil {
+				return err
+			}
+		}
+	}
+
+	if kinds != 1 {
+		return fmt.Errorf("exactly one of date, easter or dates must be set")
+	}
+	return nil
+}
+
+// Generic возвращает источник, который генерирует календарь страны по правилам.
+func (r *Rules) Generic() *Generic {
+	weekend := make([]time.Weekday, len(r.Weekend))
+	for i, wd := range r.Weekend {
+		weekend[i], _ = wd.Weekday()
+	}
+
+	return &Generic{
+		Weekend:  weekend,
+		Holidays: r.Holidays,
+	}
+}
+
+// HolidaysIn возвращает праздничные дни года y (нерабочие, с типом store.Holiday) без учета переносов выходных.
+func (r *Rules) HolidaysIn(y int) store.Months {
+	months := make(store.Months)
+	for _, rule := range r.Holidays {
+		date, ok := rule.dateIn(y)
+		if !ok || date.Year() != y {
+			continue
+		}
+
+		if months[date.Month()] == nil {
+			months[date.Month()] = make(store.Days)
+		}
+		wd, _ := store.NewWeekDay(date.Weekday())
+		months[date.Month()][date.Day()] = store.Day{WeekDay: wd, Working: false, Type: store.Holiday, Desc: rule.Desc}
+	}
+	return months
+}
+
+// dateIn возвращает дату праздника в году y или false, если в этом году праздника нет.
+func (h *HolidayRule) dateIn(y int) (time.Time, bool) {
+	if (h.From != 0 && y < h.From) || (h.To != 0 && y > h.To) {
+		return time.Time{}, false
+	}
+
+	switch {
+	case h.Date != "":
+		date, err := parseMonthDay(y, h.Date)
+		return date, err == nil
+	case h.Easter == EasterOrthodox:
+		return OrthodoxEaster(y).AddDate(0, 0, h.Offset), true
+	case h.Easter == EasterWestern:
+		return WesternEaster(y).AddDate(0, 0, h.Offset), true
+	default:
+		md, ok := h.Dates[y]
+		if !ok {
+			// Даты лунных праздников объявляются ежегодно, и правила нужно дополнять. Пока даты нет, праздник
+			// не попадает в календарь, а все дни года остаются предварительными (см. Generic).
+			log.Printf("[WARN] source/rules holiday '%s' has no date for %d, the calendar is incomplete", h.Desc, y)
+			return time.Time{}, false
+		}
+		date, err := parseMonthDay(y, md)
+		return date, err == nil
+	}
+}
+
+func parseMonthDay(y int, md string) (time.Time, error) {
+	date, err := time.Parse("01-02", md)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s', expected MM-DD", md)
+	}
+	return store.NewDate(y, date.Month(), date.Day()), nil
+}
+
+// OrthodoxEaster возвращает дату православной Пасхи по григорианскому календарю.
+func OrthodoxEaster(y int) time.Time {
+	// Алгоритм Меёса для юлианского календаря.
+	a, b, c := y%4, y%7, y%19
+	d := (19*c + 15) % 30
+	e := (2*a + 4*b - d + 34) % 7
+	month := time.Month((d + e + 114) / 31)
+	day := (d+e+114)%31 + 1
+
+	// Разница между юлианским и григорианским календарями (13 дней в 1900–2099 гг.).
+	shift := y/100 - y/400 - 2
+	return store.NewDate(y, month, day).AddDate(0, 0, shift)
+}
+
+// WesternEaster возвращает дату католической Пасхи.
+func WesternEaster(y int) time.Time {
+	// Анонимный григорианский алгоритм.
+	a := y % 19
+	b, c := y/100, y%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := time.Month((h + l - 7*m + 114) / 31)
+	day := (h+l-7*m+114)%31 + 1
+	return store.NewDate(y, month, day)
+}
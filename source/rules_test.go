@@ -0,0 +1,164 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRules(t *testing.T) {
+	assert.Equal(t, []string{"by", "kz", "ru", "uz"}, Countries())
+
+	for _, c := range Countries() {
+		r, err := LoadRules(c)
+		if assert.NoError(t, err, c) {
+			assert.NotEmpty(t, r.Name, c)
+			assert.NotEmpty(t, r.Holidays, c)
+		}
+	}
+
+	_, err := LoadRules("xx")
+	assert.ErrorContains(t, err, "unknown country")
+}
+
+func TestRules_validate(t *testing.T) {
+	r := Rules{Weekend: []store.WeekDay{store.Sunday}, Holidays: []HolidayRule{{Date: "13-01"}}}
+	assert.ErrorContains(t, r.validate(), "invalid date")
+
+	r = Rules{Weekend: []store.WeekDay{store.Sunday}, Holidays: []HolidayRule{{Date: "01-01", Easter: EasterOrthodox}}}
+	assert.ErrorContains(t, r.validate(), "exactly one")
+
+	r = Rules{Weekend: []store.WeekDay{"foo"}}
+	assert.ErrorContains(t, r.validate(), "weekend")
+}
+
+func TestEaster(t *testing.T) {
+	orthodox := map[int]string{2021: "2021-05-02", 2022: "2022-04-24", 2023: "2023-04-16", 2024: "2024-05-05"}
+	for y, exp := range orthodox {
+		assert.Equal(t, exp, OrthodoxEaster(y).Format(store.DateLayout))
+	}
+
+	western := map[int]string{2021: "2021-04-04", 2022: "2022-04-17", 2023: "2023-04-09", 2024: "2024-03-31"}
+	for y, exp := range western {
+		assert.Equal(t, exp, WesternEaster(y).Format(store.DateLayout))
+	}
+}
+
+func TestRules_Generic(t *testing.T) {
+	r, err := LoadRules("kz")
+	require.NoError(t, err)
+
+	year, err := r.Generic().GetYear(2022)
+	require.NoError(t, err)
+
+	// 1 и 2 января 2022 — сб и вс, выходные переносятся на 3 и 4 января.
+	assert.Equal(t, store.Day{WeekDay: store.Saturday, Working: false, Type: store.Holiday, Desc: "Новый год", Provisional: true}, year[time.January][1])
+	assert.Equal(t, store.Day{WeekDay: store.Sunday, Working: false, Type: store.Holiday, Desc: "Новый год", Provisional: true}, year[time.January][2])
+	assert.Equal(t, store.Weekend, year[time.January][3].Type)
+	assert.False(t, year[time.January][3].Working)
+	assert.Contains(t, year[time.January][3].Desc, "01.01")
+	assert.Equal(t, store.Weekend, year[time.January][4].Type)
+	assert.Contains(t, year[time.January][4].Desc, "02.01")
+	assert.True(t, year[time.January][5].Working)
+
+	// 7 мая — сб, 9 мая — праздник, поэтому выходной переносится на 10 мая.
+	assert.False(t, year[time.May][10].Working)
+	assert.Contains(t, year[time.May][10].Desc, "07.05")
+
+	// Курбан айт (сб) не переносится.
+	assert.Equal(t, "Курбан айт", year[time.July][9].Desc)
+	assert.True(t, year[time.July][11].Working)
+
+	// Действует с 2022 года.
+	assert.Equal(t, store.Holiday, year[time.October][25].Type)
+	assert.Equal(t, store.Normal, year[time.December][1].Type)
+
+	year, _ = r.Generic().GetYear(2026)
+	assert.Equal(t, "Курбан айт", year[time.May][27].Desc)
+
+	// Даты Курбан айта на 2030 год нет: праздник не отмечается, а дни остаются предварительными.
+	year, err = r.Generic().GetYear(2030)
+	require.NoError(t, err)
+	for _, days := range year {
+		for _, day := range days {
+			assert.NotEqual(t, "Курбан айт", day.Desc)
+			assert.True(t, day.Provisional)
+		}
+	}
+
+	r, err = LoadRules("by")
+	require.NoError(t, err)
+	year, _ = r.Generic().GetYear(2022)
+
+	// Радуница — 9-й день после православной Пасхи.
+	assert.Equal(t, store.Day{WeekDay: store.Tuesday, Working: false, Type: store.Holiday, Desc: "Радуница", Provisional: true}, year[time.May][3])
+}
+
+func TestRules_Generic_ru(t *testing.T) {
+	r, err := LoadRules("ru")
+	require.NoError(t, err)
+
+	year, err := r.Generic().GetYear(2022)
+	require.NoError(t, err)
+
+	// Январские праздники, совпавшие с выходными, не переносятся: это делает постановление Правительства.
+	assert.Equal(t, store.Day{WeekDay: store.Saturday, Working: false, Type: store.Holiday, Desc: "Новогодние каникулы", Provisional: true}, year[time.January][1])
+	assert.Equal(t, "Рождество Христово", year[time.January][7].Desc)
+	assert.Equal(t, store.Holiday, year[time.January][8].Type)
+	assert.Equal(t, store.Weekend, year[time.January][9].Type)
+	assert.True(t, year[time.January][10].Working)
+
+	// 1 мая и 12 июня — воскресенья, выходные переносятся на понедельники.
+	assert.Equal(t, store.Day{WeekDay: store.Monday, Working: false, Type: store.Weekend,
+		Desc: "Перенос выходного дня с 01.05 (Праздник Весны и Труда)", Provisional: true}, year[time.May][2])
+	assert.False(t, year[time.June][13].Working)
+	assert.Equal(t, store.Holiday, year[time.March][8].Type)
+	assert.Equal(t, store.Holiday, year[time.November][4].Type)
+
+	// В опубликованном календаре 247 рабочих дней, разница — переносы по постановлению.
+	working := 0
+	for _, days := range year {
+		for _, day := range days {
+			assert.True(t, day.Provisional)
+			if day.Working {
+				working++
+			}
+		}
+	}
+	assert.Equal(t, 249, working)
+
+	// 6 и 8 января стали праздниками в 2013 году.
+	year, _ = r.Generic().GetYear(2012)
+	assert.Equal(t, store.Normal, year[time.January][6].Type)
+	assert.Equal(t, store.Holiday, year[time.January][7].Type)
+}
+
+func TestRules_HolidaysIn(t *testing.T) {
+	r, err := LoadRules("ru")
+	require.NoError(t, err)
+
+	holidays := r.HolidaysIn(2022)
+	assert.Len(t, holidays[time.January], 8)
+	assert.Equal(t, store.Day{WeekDay: store.Sunday, Working: false, Type: store.Holiday, Desc: "Праздник Весны и Труда"},
+		holidays[time.May][1])
+	assert.NotContains(t, holidays[time.May], 2, "transfers are not included")
+
+	assert.Len(t, r.HolidaysIn(2012)[time.January], 6)
+}
+
+func TestRules_lunarDates(t *testing.T) {
+	// Даты праздников по лунному календарю должны быть известны на текущий год.
+	y := 2026
+	for _, country := range []string{"kz", "uz"} {
+		r, err := LoadRules(country)
+		require.NoError(t, err)
+		for _, h := range r.Holidays {
+			if len(h.Dates) > 0 {
+				assert.Contains(t, h.Dates, y, "%s: %s", country, h.Desc)
+			}
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package store
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
 
 type DayType string
 
@@ -39,11 +44,31 @@ func NewWeekDay(wd time.Weekday) (WeekDay, bool) {
 	// @formatter:on
 }
 
+// Weekday — обратное преобразование к NewWeekDay.
+func (wd WeekDay) Weekday() (time.Weekday, bool) {
+	// @formatter:off
+	switch wd {
+	case Monday:    return time.Monday,    true
+	case Tuesday:   return time.Tuesday,   true
+	case Wednesday: return time.Wednesday, true
+	case Thursday:  return time.Thursday,  true
+	case Friday:    return time.Friday,    true
+	case Saturday:  return time.Saturday,  true
+	case Sunday:    return time.Sunday,    true
+	default:        return 0,              false
+	}
+	// @formatter:on
+}
+
 type Day struct {
 	WeekDay WeekDay `json:"weekDay,omitempty" yaml:"weekDay"`
 	Working bool    `json:"working" yaml:"working"`
 	Type    DayType `json:"type,omitempty" yaml:"type"`
 	Desc    string  `json:"desc,omitempty" yaml:"desc"`
+
+	// Provisional — день сгенерирован по правилам (source.Generic) и не подтвержден
+	// ни парсером, ни постановлением, ни переопределением.
+	Provisional bool `json:"provisional,omitempty" yaml:"provisional"`
 }
 
 type Days map[int]Day
@@ -65,3 +90,135 @@ func (y Months) Copy() Months {
 	}
 	return yCopy
 }
+
+// Hash возвращает хеш содержимого календаря: одинаковые календари имеют одинаковый хеш.
+func (y Months) Hash() string {
+	// json.Marshal сортирует ключи map, поэтому результат не зависит от порядка обхода.
+	data, _ := json.Marshal(y)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// YearStatus — насколько данные года подтверждены источниками (см. Day.Provisional).
+type YearStatus string
+
+const (
+	StatusConfirmed YearStatus = "confirmed" // Предварительных дней нет.
+	StatusPartial   YearStatus = "partial"   // Часть дней предварительные.
+	StatusGenerated YearStatus = "generated" // Все дни предварительные: год только сгенерирован по правилам.
+
+	// StatusUnknown — статус не сохранен: год сохранен до появления статусов и с тех пор не менялся.
+	// Дни таких годов не отмечены как предварительные, даже если они были сгенерированы по правилам,
+	// поэтому статус по ним не вычисляется.
+	StatusUnknown YearStatus = "unknown"
+)
+
+// Status возвращает статус года по числу предварительных дней. Вычисляется при сохранении года,
+// для сохраненных данных нужно использовать статус из метаданных (YearMeta.Status).
+func (y Months) Status() YearStatus {
+	total, provisional := 0, 0
+	for _, days := range y {
+		for _, day := range days {
+			total++
+			if day.Provisional {
+				provisional++
+			}
+		}
+	}
+
+	switch {
+	case provisional == 0:
+		return StatusConfirmed
+	case provisional == total:
+		return StatusGenerated
+	default:
+		return StatusPartial
+	}
+}
+
+// YearMeta — сведения о годе, сохраненном в хранилище.
+type YearMeta struct {
+	Modified time.Time  `json:"modified"`         // Когда содержимое года изменилось в последний раз.
+	Hash     string     `json:"hash"`             // Хеш содержимого года (Months.Hash).
+	Status   YearStatus `json:"status,omitempty"` // Статус содержимого года (Months.Status).
+}
+
+// NextYearMeta возвращает метаданные для нового содержимого года data, если до этого хранилось содержимое
+// с метаданными prev (nil, если год сохраняется впервые). Время изменения обновляется, только если изменилось
+// содержимое, чтобы ежедневная синхронизация без изменений не сбрасывала HTTP-кеши клиентов.
+func NextYearMeta(prev *YearMeta, data Months) YearMeta {
+	hash := data.Hash()
+	if prev != nil && prev.Hash == hash {
+		// Метаданные, сохраненные до появления статуса, дополняются при следующей синхронизации.
+		meta := *prev
+		meta.Status = data.Status()
+		return meta
+	}
+	return YearMeta{
+		Modified: time.Now().UTC(),
+		Hash:     hash,
+		Status:   data.Status(),
+	}
+}
+
+// DaySource — названия источников, которые определили поля дня при слиянии календаря.
+// Пустое поле — ни один источник его не заполнил.
+type DaySource struct {
+	WeekDay string `json:"weekDay,omitempty"`
+	Working string `json:"working,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Desc    string `json:"desc,omitempty"`
+}
+
+// Provenance — источники полей каждого дня года, структура та же, что у Months.
+type Provenance map[time.Month]map[int]DaySource
+
+func (p Provenance) Copy() Provenance {
+	if p == nil {
+		return nil
+	}
+
+	pCopy := make(Provenance, len(p))
+	for mon, days := range p {
+		daysCopy := make(map[int]DaySource, len(days))
+		for dayNum, src := range days {
+			daysCopy[dayNum] = src
+		}
+		pCopy[mon] = daysCopy
+	}
+	return pCopy
+}
+
+// Revision — версия календаря года, сохраненная при синхронизации.
+type Revision struct {
+	Time       time.Time  `json:"time"`                 // Когда версия была сохранена.
+	Sources    []string   `json:"sources"`              // Источники, данные которых вошли в календарь.
+	Hash       string     `json:"hash"`                 // Хеш содержимого (Months.Hash).
+	Status     YearStatus `json:"status,omitempty"`     // Статус содержимого (Months.Status).
+	Months     Months     `json:"months,omitempty"`     // Содержимое года.
+	Provenance Provenance `json:"provenance,omitempty"` // Источники полей каждого дня, в истории не хранятся.
+}
+
+// NewRevision возвращает версию года с содержимым data, сохраненную сейчас.
+func NewRevision(data Months, sources []string) Revision {
+	return Revision{
+		Time:    time.Now().UTC(),
+		Sources: sources,
+		Hash:    data.Hash(),
+		Status:  data.Status(),
+		Months:  data,
+	}
+}
+
+// RevisionAt возвращает версию, которая действовала в момент t: последнюю из сохраненных не позднее t.
+// history должна быть упорядочена по времени.
+func RevisionAt(history []Revision, t time.Time) (*Revision, bool) {
+	var found *Revision
+	for i := range history {
+		if history[i].Time.After(t) {
+			break
+		}
+		found = &history[i]
+	}
+	return found, found != nil
+}
@@ -1,8 +1,10 @@
 package store
 
 import (
-	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestYear_Copy(t *testing.T) {
@@ -17,3 +19,72 @@ func TestYear_Copy(t *testing.T) {
 
 	assert.NotEqual(t, yCopy, yOrig)
 }
+
+func TestNextYearMeta(t *testing.T) {
+	y1 := Months{1: Days{1: {Working: false, Type: Holiday}}}
+	y2 := Months{1: Days{1: {Working: true, Type: Normal}}}
+
+	m1 := NextYearMeta(nil, y1)
+	assert.NotEmpty(t, m1.Hash)
+	assert.WithinDuration(t, time.Now(), m1.Modified, time.Second)
+
+	// Содержимое не изменилось — метаданные тоже.
+	prev := m1
+	prev.Modified = prev.Modified.Add(-time.Hour)
+	assert.Equal(t, prev, NextYearMeta(&prev, y1.Copy()))
+
+	m2 := NextYearMeta(&prev, y2)
+	assert.NotEqual(t, m1.Hash, m2.Hash)
+	assert.True(t, m2.Modified.After(prev.Modified))
+
+	// Статус дополняется в метаданных, сохраненных без него.
+	prev.Status = ""
+	assert.Equal(t, StatusConfirmed, NextYearMeta(&prev, y1).Status)
+}
+
+func TestMonths_Status(t *testing.T) {
+	y := Months{1: Days{
+		1: {Working: false, Type: Holiday, Provisional: true},
+		2: {Working: false, Type: Holiday, Provisional: true},
+	}}
+	assert.Equal(t, StatusGenerated, y.Status())
+
+	y[1][2] = Day{Working: false, Type: Holiday}
+	assert.Equal(t, StatusPartial, y.Status())
+
+	y[1][1] = Day{Working: false, Type: Holiday}
+	assert.Equal(t, StatusConfirmed, y.Status())
+}
+
+func TestWeekDay_Weekday(t *testing.T) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		storeWd, ok := NewWeekDay(wd)
+		assert.True(t, ok)
+
+		res, ok := storeWd.Weekday()
+		assert.True(t, ok)
+		assert.Equal(t, wd, res)
+	}
+
+	_, ok := WeekDay("foo").Weekday()
+	assert.False(t, ok)
+}
+
+func TestRevisionAt(t *testing.T) {
+	t1 := time.Date(2022, time.January, 1, 10, 0, 0, 0, time.UTC)
+	t2 := time.Date(2022, time.March, 1, 10, 0, 0, 0, time.UTC)
+	history := []Revision{{Time: t1, Hash: "1"}, {Time: t2, Hash: "2"}}
+
+	_, ok := RevisionAt(history, t1.Add(-time.Second))
+	assert.False(t, ok)
+
+	rev, ok := RevisionAt(history, t1)
+	assert.True(t, ok)
+	assert.Equal(t, "1", rev.Hash)
+
+	rev, _ = RevisionAt(history, t2.Add(-time.Second))
+	assert.Equal(t, "1", rev.Hash)
+
+	rev, _ = RevisionAt(history, t2.Add(time.Hour))
+	assert.Equal(t, "2", rev.Hash)
+}
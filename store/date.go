@@ -0,0 +1,64 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// DateLayout — формат даты, который используется в REST API (ISO 8601).
+const DateLayout = "2006-01-02"
+
+// DateDay — описание дня вместе с его датой.
+// В JSON дата выводится в поле date, остальные поля совпадают с Day.
+type DateDay struct {
+	Date time.Time
+	Day
+}
+
+// NewDate возвращает полночь указанной даты в UTC. Все вычисления над датами ведутся в UTC,
+// чтобы переходы на летнее время не влияли на шаг в один день.
+func NewDate(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDate разбирает дату в формате DateLayout.
+func ParseDate(s string) (time.Time, error) {
+	return time.Parse(DateLayout, s)
+}
+
+func (d DateDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Date string `json:"date"`
+		Day
+	}{
+		Date: d.Date.Format(DateLayout),
+		Day:  d.Day,
+	})
+}
+
+// DateDays возвращает дни месяца mon года y в порядке возрастания даты.
+func (m Days) DateDays(y int, mon time.Month) []DateDay {
+	res := make([]DateDay, 0, len(m))
+	for dayNum, day := range m {
+		res = append(res, DateDay{Date: NewDate(y, mon, dayNum), Day: day})
+	}
+	sortDateDays(res)
+	return res
+}
+
+// DateDays возвращает все дни года y в порядке возрастания даты.
+func (y Months) DateDays(year int) []DateDay {
+	res := make([]DateDay, 0, 366)
+	for mon, days := range y {
+		res = append(res, days.DateDays(year, mon)...)
+	}
+	sortDateDays(res)
+	return res
+}
+
+func sortDateDays(days []DateDay) {
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date.Before(days[j].Date)
+	})
+}
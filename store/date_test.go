@@ -0,0 +1,39 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDateDay_MarshalJSON(t *testing.T) {
+	d := DateDay{
+		Date: NewDate(2022, time.May, 9),
+		Day:  Day{WeekDay: Monday, Working: false, Type: Holiday, Desc: "День Победы"},
+	}
+
+	js, err := json.Marshal(d)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"date": "2022-05-09", "weekDay": "mon", "working": false, "type": "holiday", "desc": "День Победы"}`, string(js))
+}
+
+func TestMonths_DateDays(t *testing.T) {
+	y := Months{
+		time.February: {
+			1: {Working: true},
+		},
+		time.January: {
+			2:  {Working: false},
+			10: {Working: true},
+		},
+	}
+
+	expDays := []DateDay{
+		{Date: NewDate(2022, time.January, 2), Day: Day{Working: false}},
+		{Date: NewDate(2022, time.January, 10), Day: Day{Working: true}},
+		{Date: NewDate(2022, time.February, 1), Day: Day{Working: true}},
+	}
+	assert.Equal(t, expDays, y.DateDays(2022))
+}
@@ -13,10 +13,19 @@ import (
 	"go.etcd.io/bbolt"
 )
 
-const calBucket = "cal"
+const (
+	calBucket     = "cal"
+	metaBucket    = "meta"
+	historyBucket = "history"
+	provBucket    = "provenance"
+)
+
+// historyKeyLayout — формат времени в ключах истории, при котором порядок ключей совпадает с порядком версий.
+const historyKeyLayout = "20060102T150405.000000000Z"
 
 // Bolt хранят все данные в одном бакете (const calBucket).
 // По ключу /<y>/<m> хранится JSON, описывающий все дни месяца. Оба ключа - числовые.
+// При сохранении год заменяется целиком: месяцы, которых нет в новых данных, удаляются.
 //
 // Есть два паттерна использования данного сервиса: клиенты могут обращаться через REST API всякий раз, когда
 // нужна информация о дне/неделе, либо запросить, например, год и закешировать у себя. Первое подходит, например,
@@ -25,8 +34,23 @@ const calBucket = "cal"
 // Хранение каждого года в отдельном ключе неудобно с т. з. отладки и не имеет преимуществ по производительности для
 // случая обработки большого кол-ва данных. Хранение каждого дня в отдельном ключе негативно скажется на длительности
 // обработки запросов к месяцу. Хранение каждого месяца в отдельном ключе пока что выглядит самым удачным решением.
+//
+// Метаданные годов (store.YearMeta) хранятся в отдельном бакете (const metaBucket) по ключу /<y> в виде JSON.
+//
+// Версии годов (store.Revision) хранятся в бакете historyBucket по ключу /<y>/<время сохранения в UTC>
+// в виде JSON. Новая версия добавляется, только если содержимое года изменилось.
+//
+// Источники полей дней последней версии года (store.Provenance) хранятся в бакете provBucket по ключу /<y>
+// в виде JSON.
+//
+// Именованные календари (см. метод Calendar) хранятся в том же файле в бакетах <calBucket>:<name>,
+// <metaBucket>:<name>, <historyBucket>:<name> и <provBucket>:<name>, структура ключей та же.
 type Bolt struct {
-	db *bbolt.DB
+	db       *bbolt.DB
+	calName  string
+	metaName string
+	histName string
+	provName string
 }
 
 func NewBolt(file string) (*Bolt, error) {
@@ -37,10 +61,26 @@ func NewBolt(file string) (*Bolt, error) {
 	log.Printf("[DEBUG] store/bolt opened %s successfully", file)
 
 	return &Bolt{
-		db: b,
+		db:       b,
+		calName:  calBucket,
+		metaName: metaBucket,
+		histName: historyBucket,
+		provName: provBucket,
 	}, nil
 }
 
+// Calendar возвращает хранилище именованного календаря (например, регионального) в том же файле БД.
+// Закрывать его не нужно, достаточно закрыть основное хранилище.
+func (b *Bolt) Calendar(name string) *Bolt {
+	return &Bolt{
+		db:       b.db,
+		calName:  calBucket + ":" + name,
+		metaName: metaBucket + ":" + name,
+		histName: historyBucket + ":" + name,
+		provName: provBucket + ":" + name,
+	}
+}
+
 func (b *Bolt) Close() error {
 	if err := b.db.Close(); err != nil {
 		return fmt.Errorf("cannot close bolt store: %w", err)
@@ -65,7 +105,7 @@ func (b *Bolt) FindDay(y int, mon time.Month, d int) (*store.Day, bool) {
 
 func (b *Bolt) FindMonth(y int, mon time.Month) (d store.Days, ok bool) {
 	_ = b.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(calBucket))
+		bucket := tx.Bucket([]byte(b.calName))
 		if bucket == nil {
 			ok = false
 			return nil
@@ -91,7 +131,7 @@ func (b *Bolt) FindMonth(y int, mon time.Month) (d store.Days, ok bool) {
 
 func (b *Bolt) FindYear(y int) (m store.Months, ok bool) {
 	_ = b.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(calBucket))
+		bucket := tx.Bucket([]byte(b.calName))
 		if bucket == nil {
 			ok = false
 			return nil
@@ -137,11 +177,63 @@ func (b *Bolt) FindYear(y int) (m store.Months, ok bool) {
 	return
 }
 
+func (b *Bolt) FindYearMeta(y int) (meta *store.YearMeta, ok bool) {
+	_ = b.db.View(func(tx *bbolt.Tx) error {
+		meta = b.findYearMeta(tx, y)
+		ok = meta != nil
+		return nil
+	})
+	return
+}
+
+func (b *Bolt) findYearMeta(tx *bbolt.Tx, y int) *store.YearMeta {
+	bucket := tx.Bucket([]byte(b.metaName))
+	if bucket == nil {
+		return nil
+	}
+
+	key := fmt.Sprintf("/%d", y)
+	metaJson := bucket.Get([]byte(key))
+	log.Printf("[DEBUG] store/bolt get meta key=%s len=%d", key, len(metaJson))
+	if metaJson == nil {
+		return nil
+	}
+
+	meta := &store.YearMeta{}
+	if err := json.Unmarshal(metaJson, meta); err != nil {
+		log.Printf("[WARN] bolt: invalid year meta at %s: %v", key, err)
+		return nil
+	}
+	return meta
+}
+
 func (b *Bolt) PutYear(y int, data store.Months) error {
+	return b.PutRevision(y, store.NewRevision(data, nil))
+}
+
+// PutRevision сохраняет год так же, как PutYear, и добавляет rev в историю, если содержимое года изменилось.
+// rev должна быть создана через store.NewRevision. Источники полей дней (rev.Provenance) заменяются всегда.
+func (b *Bolt) PutRevision(y int, rev store.Revision) error {
+	data := rev.Months
 	return b.db.Update(func(tx *bbolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte(calBucket))
+		bucket, err := tx.CreateBucketIfNotExists([]byte(b.calName))
 		if err != nil {
-			return fmt.Errorf("bolt cannot create bucket '%s': %v", calBucket, err)
+			return fmt.Errorf("bolt cannot create bucket '%s': %v", b.calName, err)
+		}
+
+		if err := b.putYearMeta(tx, y, data); err != nil {
+			return err
+		}
+		if err := b.putRevision(tx, y, rev); err != nil {
+			return err
+		}
+		if err := b.putProvenance(tx, y, rev.Provenance); err != nil {
+			return err
+		}
+		// Год заменяется целиком, как и в других хранилищах: иначе месяцы, которых нет в data, остались бы
+		// в хранилище, и хеш года не соответствовал бы его содержимому.
+		if err := b.deleteYear(bucket, y); err != nil {
+			return err
 		}
 
 		for m, days := range data {
@@ -161,6 +253,163 @@ func (b *Bolt) PutYear(y int, data store.Months) error {
 	})
 }
 
+// deleteYear удаляет все месяцы года y из бакета календаря.
+func (b *Bolt) deleteYear(bucket *bbolt.Bucket, y int) error {
+	prefix := []byte(fmt.Sprintf("/%d/", y))
+
+	// Ключи удаляются после обхода курсором, т. к. удаление во время обхода может пропустить ключи.
+	var keys [][]byte
+	c := bucket.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+
+	for _, k := range keys {
+		log.Printf("[DEBUG] store/bolt delete key=%s", k)
+		if err := bucket.Delete(k); err != nil {
+			return fmt.Errorf("bolt cannot delete %s: %v", k, err)
+		}
+	}
+	return nil
+}
+
+func (b *Bolt) putYearMeta(tx *bbolt.Tx, y int, data store.Months) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(b.metaName))
+	if err != nil {
+		return fmt.Errorf("bolt cannot create bucket '%s': %v", b.metaName, err)
+	}
+
+	meta := store.NextYearMeta(b.findYearMeta(tx, y), data)
+
+	key := []byte(fmt.Sprintf("/%d", y))
+	val, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("bolt cannot marshal meta %s: %v", key, err)
+	}
+
+	log.Printf("[DEBUG] store/bolt put meta key=%s len=%d", key, len(val))
+	if err := bucket.Put(key, val); err != nil {
+		return fmt.Errorf("bolt cannot put meta %s: %v", key, err)
+	}
+	return nil
+}
+
+func (b *Bolt) putProvenance(tx *bbolt.Tx, y int, prov store.Provenance) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(b.provName))
+	if err != nil {
+		return fmt.Errorf("bolt cannot create bucket '%s': %v", b.provName, err)
+	}
+
+	key := []byte(fmt.Sprintf("/%d", y))
+	if prov == nil {
+		if err := bucket.Delete(key); err != nil {
+			return fmt.Errorf("bolt cannot delete provenance %s: %v", key, err)
+		}
+		return nil
+	}
+
+	val, err := json.Marshal(prov)
+	if err != nil {
+		return fmt.Errorf("bolt cannot marshal provenance %s: %v", key, err)
+	}
+
+	log.Printf("[DEBUG] store/bolt put provenance key=%s len=%d", key, len(val))
+	if err := bucket.Put(key, val); err != nil {
+		return fmt.Errorf("bolt cannot put provenance %s: %v", key, err)
+	}
+	return nil
+}
+
+// FindProvenance возвращает источники полей каждого дня года, сохраненные вместе с последней версией.
+func (b *Bolt) FindProvenance(y int) (p store.Provenance, ok bool) {
+	_ = b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(b.provName))
+		if bucket == nil {
+			return nil
+		}
+
+		key := fmt.Sprintf("/%d", y)
+		val := bucket.Get([]byte(key))
+		log.Printf("[DEBUG] store/bolt get provenance key=%s len=%d", key, len(val))
+		if val == nil {
+			return nil
+		}
+
+		if err := json.Unmarshal(val, &p); err != nil {
+			log.Printf("[WARN] bolt: invalid provenance at %s: %v", key, err)
+			p = nil
+			return nil
+		}
+
+		ok = true
+		return nil
+	})
+	return
+}
+
+// FindHistory возвращает все версии года в порядке сохранения.
+func (b *Bolt) FindHistory(y int) (h []store.Revision, ok bool) {
+	_ = b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(b.histName))
+		if bucket == nil {
+			return nil
+		}
+
+		prefix := []byte(fmt.Sprintf("/%d/", y))
+		c := bucket.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var rev store.Revision
+			if err := json.Unmarshal(v, &rev); err != nil {
+				log.Printf("[WARN] bolt: invalid revision at %s: %v", k, err)
+				continue
+			}
+			h = append(h, rev)
+		}
+
+		ok = len(h) > 0
+		return nil
+	})
+	return
+}
+
+func (b *Bolt) putRevision(tx *bbolt.Tx, y int, rev store.Revision) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(b.histName))
+	if err != nil {
+		return fmt.Errorf("bolt cannot create bucket '%s': %v", b.histName, err)
+	}
+
+	// Последний ключ года: переходим к первому ключу после префикса и делаем шаг назад.
+	prefix := []byte(fmt.Sprintf("/%d/", y))
+	c := bucket.Cursor()
+	k, _ := c.Seek(append(prefix[:len(prefix):len(prefix)], 0xff))
+	if k == nil {
+		k, _ = c.Last()
+	} else {
+		k, _ = c.Prev()
+	}
+
+	if k != nil && bytes.HasPrefix(k, prefix) {
+		var last store.Revision
+		if err := json.Unmarshal(bucket.Get(k), &last); err == nil && last.Hash == rev.Hash {
+			return nil
+		}
+	}
+
+	// Источники полей дней хранятся только для последней версии (provBucket).
+	rev.Provenance = nil
+	key := []byte(fmt.Sprintf("/%d/%s", y, rev.Time.UTC().Format(historyKeyLayout)))
+	val, err := json.Marshal(rev)
+	if err != nil {
+		return fmt.Errorf("bolt cannot marshal revision %s: %v", key, err)
+	}
+
+	log.Printf("[DEBUG] store/bolt put revision key=%s len=%d", key, len(val))
+	if err := bucket.Put(key, val); err != nil {
+		return fmt.Errorf("bolt cannot put revision %s: %v", key, err)
+	}
+	return nil
+}
+
 func (b *Bolt) Backup(w io.Writer) error {
 	return b.db.View(func(tx *bbolt.Tx) error {
 		log.Printf("[DEBUG] store/bolt writing backup len=%d", tx.Size())
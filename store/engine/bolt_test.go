@@ -38,6 +38,127 @@ func TestBolt(t *testing.T) {
 	d, ok := b.FindDay(2022, time.January, 2)
 	assert.True(t, ok)
 	assert.Equal(t, sample2022[time.January][2], *d)
+
+	// Год заменяется целиком, и хеш в метаданных соответствует содержимому. Другие годы не меняются.
+	require.NoError(t, b.PutYear(2021, sample2022))
+	provisional := store.Months{time.March: {1: {WeekDay: store.Tuesday, Working: true, Type: store.Normal, Provisional: true}}}
+	require.NoError(t, b.PutYear(2022, provisional))
+
+	y, _ = b.FindYear(2022)
+	assert.Equal(t, provisional, y)
+	_, ok = b.FindMonth(2022, time.January)
+	assert.False(t, ok)
+	meta, _ := b.FindYearMeta(2022)
+	assert.Equal(t, y.Hash(), meta.Hash)
+	assert.Equal(t, store.StatusGenerated, meta.Status)
+
+	y, _ = b.FindYear(2021)
+	assert.Equal(t, sample2022, y)
+}
+
+func TestBolt_FindYearMeta(t *testing.T) {
+	b, _ := makeBolt(t)
+	defer b.Close()
+
+	_, ok := b.FindYearMeta(2022)
+	assert.False(t, ok)
+
+	err := b.PutYear(2022, sample2022)
+	require.NoError(t, err)
+
+	meta, ok := b.FindYearMeta(2022)
+	assert.True(t, ok)
+	assert.Equal(t, sample2022.Hash(), meta.Hash)
+	assert.False(t, meta.Modified.IsZero())
+
+	// Повторное сохранение того же календаря не меняет время изменения.
+	err = b.PutYear(2022, sample2022)
+	require.NoError(t, err)
+	meta2, _ := b.FindYearMeta(2022)
+	assert.Equal(t, meta.Modified.UnixNano(), meta2.Modified.UnixNano())
+}
+
+func TestBolt_Calendar(t *testing.T) {
+	b, _ := makeBolt(t)
+	defer b.Close()
+
+	reg := b.Calendar("tatarstan")
+	err := reg.PutYear(2022, sample2022)
+	require.NoError(t, err)
+
+	_, ok := b.FindYear(2022)
+	assert.False(t, ok)
+	_, ok = b.FindYearMeta(2022)
+	assert.False(t, ok)
+
+	y, ok := b.Calendar("tatarstan").FindYear(2022)
+	assert.True(t, ok)
+	assert.Equal(t, sample2022, y)
+
+	_, ok = b.Calendar("bashkortostan").FindYear(2022)
+	assert.False(t, ok)
+}
+
+func TestBolt_FindHistory(t *testing.T) {
+	b, _ := makeBolt(t)
+	defer b.Close()
+
+	_, ok := b.FindHistory(2022)
+	assert.False(t, ok)
+
+	require.NoError(t, b.PutRevision(2022, store.NewRevision(sample2022, []string{"generic"})))
+	require.NoError(t, b.PutYear(2022, sample2022)) // Без изменений.
+	require.NoError(t, b.PutYear(2021, sample2022))
+
+	changed := sample2022.Copy()
+	changed[time.February][1] = store.Day{WeekDay: store.Tuesday, Working: false, Type: store.Holiday}
+	require.NoError(t, b.PutRevision(2022, store.NewRevision(changed, []string{"generic", "override"})))
+
+	h, ok := b.FindHistory(2022)
+	require.True(t, ok)
+	require.Len(t, h, 2)
+	assert.Equal(t, []string{"generic"}, h[0].Sources)
+	assert.Equal(t, sample2022, h[0].Months)
+	assert.Equal(t, []string{"generic", "override"}, h[1].Sources)
+	assert.Equal(t, changed, h[1].Months)
+	assert.True(t, h[0].Time.Before(h[1].Time))
+
+	h, _ = b.FindHistory(2021)
+	assert.Len(t, h, 1)
+
+	// Возврат к прежнему содержимому — тоже новая версия.
+	require.NoError(t, b.PutYear(2022, sample2022))
+	h, _ = b.FindHistory(2022)
+	assert.Len(t, h, 3)
+}
+
+func TestBolt_FindProvenance(t *testing.T) {
+	b, _ := makeBolt(t)
+	defer b.Close()
+
+	_, ok := b.FindProvenance(2022)
+	assert.False(t, ok)
+
+	prov := store.Provenance{time.February: {1: {WeekDay: "generic", Working: "consultant", Type: "consultant"}}}
+	rev := store.NewRevision(sample2022, []string{"generic", "consultant"})
+	rev.Provenance = prov
+	require.NoError(t, b.PutRevision(2022, rev))
+
+	p, ok := b.FindProvenance(2022)
+	require.True(t, ok)
+	assert.Equal(t, prov, p)
+
+	h, _ := b.FindHistory(2022)
+	require.Len(t, h, 1)
+	assert.Nil(t, h[0].Provenance, "provenance must be stored for the last revision only")
+
+	_, ok = b.Calendar("tatarstan").FindProvenance(2022)
+	assert.False(t, ok)
+
+	// Сохранение без сведений об источниках удаляет прежние.
+	require.NoError(t, b.PutYear(2022, sample2022))
+	_, ok = b.FindProvenance(2022)
+	assert.False(t, ok)
 }
 
 func TestBolt_backup(t *testing.T) {
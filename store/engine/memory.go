@@ -7,13 +7,20 @@ import (
 )
 
 type Memory struct {
-	store map[int]store.Months
-	mu    sync.RWMutex
+	store     map[int]store.Months
+	meta      map[int]store.YearMeta
+	history   map[int][]store.Revision
+	prov      map[int]store.Provenance
+	calendars map[string]*Memory
+	mu        sync.RWMutex
 }
 
 func NewMemory() *Memory {
 	return &Memory{
-		store: make(map[int]store.Months, 3),
+		store:   make(map[int]store.Months, 3),
+		meta:    make(map[int]store.YearMeta, 3),
+		history: make(map[int][]store.Revision, 3),
+		prov:    make(map[int]store.Provenance, 3),
 	}
 }
 
@@ -21,6 +28,24 @@ func (m *Memory) Close() error {
 	return nil
 }
 
+// Calendar возвращает хранилище именованного календаря (например, регионального).
+// При повторном вызове с тем же именем возвращается то же хранилище.
+func (m *Memory) Calendar(name string) *Memory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.calendars == nil {
+		m.calendars = make(map[string]*Memory, 1)
+	}
+
+	cal, ok := m.calendars[name]
+	if !ok {
+		cal = NewMemory()
+		m.calendars[name] = cal
+	}
+	return cal
+}
+
 func (m *Memory) FindDay(y int, mon time.Month, d int) (*store.Day, bool) {
 	month, ok := m.FindMonth(y, mon)
 	if !ok {
@@ -63,10 +88,88 @@ func (m *Memory) FindYear(y int) (store.Months, bool) {
 	return year.Copy(), true
 }
 
+func (m *Memory) FindYearMeta(y int) (*store.YearMeta, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	meta, ok := m.meta[y]
+	if !ok {
+		return nil, false
+	}
+
+	return &meta, true
+}
+
+// FindProvenance возвращает источники полей каждого дня года, сохраненные вместе с последней версией.
+func (m *Memory) FindProvenance(y int) (store.Provenance, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	prov, ok := m.prov[y]
+	if !ok {
+		return nil, false
+	}
+
+	return prov.Copy(), true
+}
+
+// FindHistory возвращает все версии года в порядке сохранения.
+func (m *Memory) FindHistory(y int) ([]store.Revision, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	history, ok := m.history[y]
+	if !ok {
+		return nil, false
+	}
+
+	res := make([]store.Revision, len(history))
+	for i, rev := range history {
+		res[i] = rev
+		res[i].Months = rev.Months.Copy()
+	}
+	return res, true
+}
+
 func (m *Memory) PutYear(y int, data store.Months) error {
+	return m.PutRevision(y, store.NewRevision(data, nil))
+}
+
+// PutRevision сохраняет год так же, как PutYear, и добавляет rev в историю, если содержимое года изменилось.
+// rev должна быть создана через store.NewRevision. Источники полей дней (rev.Provenance) заменяются всегда.
+func (m *Memory) PutRevision(y int, rev store.Revision) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	data := rev.Months
+
+	var prev *store.YearMeta
+	if meta, ok := m.meta[y]; ok {
+		prev = &meta
+	}
+	if m.meta == nil {
+		m.meta = make(map[int]store.YearMeta, 3)
+	}
+
 	m.store[y] = data.Copy()
+	m.meta[y] = store.NextYearMeta(prev, data)
+
+	if m.prov == nil {
+		m.prov = make(map[int]store.Provenance, 3)
+	}
+	if rev.Provenance != nil {
+		m.prov[y] = rev.Provenance.Copy()
+	} else {
+		delete(m.prov, y)
+	}
+
+	if m.history == nil {
+		m.history = make(map[int][]store.Revision, 3)
+	}
+	if h := m.history[y]; len(h) == 0 || h[len(h)-1].Hash != rev.Hash {
+		rev.Months = data.Copy()
+		rev.Provenance = nil // Источники полей дней хранятся только для последней версии (prov).
+		m.history[y] = append(h, rev)
+	}
 	return nil
 }
@@ -109,16 +109,114 @@ func TestMemory_PutYear(t *testing.T) {
 	err := mem.PutYear(2022, yearToSave)
 	assert.NoError(t, err)
 
-	expMem := &Memory{store: map[int]store.Months{
+	expStore := map[int]store.Months{
 		2022: {
 			1: {
 				2: {Working: false, Type: store.Holiday},
 			},
 		},
-	}}
-	assert.Equal(t, expMem, mem)
+	}
+	assert.Equal(t, expStore, mem.store)
 
 	// Изменение аргумента для PutYear не должно влиять на mem.store.
 	yearToSave[1][2] = store.Day{Working: true}
 	assert.False(t, mem.store[2022][1][2].Working)
 }
+
+func TestMemory_FindYearMeta(t *testing.T) {
+	mem := NewMemory()
+
+	_, ok := mem.FindYearMeta(2022)
+	assert.False(t, ok)
+
+	yearToSave := store.Months{
+		1: {
+			2: {Working: false, Type: store.Holiday},
+		},
+	}
+	err := mem.PutYear(2022, yearToSave)
+	assert.NoError(t, err)
+
+	meta, ok := mem.FindYearMeta(2022)
+	assert.True(t, ok)
+	assert.Equal(t, yearToSave.Hash(), meta.Hash)
+	assert.False(t, meta.Modified.IsZero())
+
+	// Повторное сохранение того же календаря не меняет время изменения.
+	err = mem.PutYear(2022, yearToSave)
+	assert.NoError(t, err)
+	meta2, _ := mem.FindYearMeta(2022)
+	assert.Equal(t, meta, meta2)
+}
+
+func TestMemory_Calendar(t *testing.T) {
+	mem := NewMemory()
+
+	yearToSave := store.Months{
+		1: {
+			2: {Working: false, Type: store.Holiday},
+		},
+	}
+	err := mem.Calendar("tatarstan").PutYear(2022, yearToSave)
+	assert.NoError(t, err)
+
+	_, ok := mem.FindYear(2022)
+	assert.False(t, ok)
+
+	y, ok := mem.Calendar("tatarstan").FindYear(2022)
+	assert.True(t, ok)
+	assert.Equal(t, yearToSave, y)
+
+	_, ok = mem.Calendar("bashkortostan").FindYear(2022)
+	assert.False(t, ok)
+}
+
+func TestMemory_FindHistory(t *testing.T) {
+	mem := NewMemory()
+
+	_, ok := mem.FindHistory(2022)
+	assert.False(t, ok)
+
+	v1 := store.Months{1: {2: {Working: false, Type: store.Holiday}}}
+	v2 := store.Months{1: {2: {Working: true, Type: store.Normal}}}
+
+	assert.NoError(t, mem.PutRevision(2022, store.NewRevision(v1, []string{"generic"})))
+	assert.NoError(t, mem.PutYear(2022, v1)) // Без изменений.
+	assert.NoError(t, mem.PutRevision(2022, store.NewRevision(v2, []string{"generic", "override"})))
+
+	h, ok := mem.FindHistory(2022)
+	assert.True(t, ok)
+	if assert.Len(t, h, 2) {
+		assert.Equal(t, []string{"generic"}, h[0].Sources)
+		assert.Equal(t, v1, h[0].Months)
+		assert.Equal(t, v2, h[1].Months)
+	}
+}
+
+func TestMemory_FindProvenance(t *testing.T) {
+	mem := NewMemory()
+
+	_, ok := mem.FindProvenance(2022)
+	assert.False(t, ok)
+
+	prov := store.Provenance{1: {2: {Working: "override", Type: "override", Desc: "override"}}}
+	rev := store.NewRevision(store.Months{1: {2: {Working: false, Type: store.Holiday}}}, []string{"override"})
+	rev.Provenance = prov
+	assert.NoError(t, mem.PutRevision(2022, rev))
+
+	p, ok := mem.FindProvenance(2022)
+	assert.True(t, ok)
+	assert.Equal(t, prov, p)
+
+	p[1][2] = store.DaySource{} // Возвращается копия.
+	p, _ = mem.FindProvenance(2022)
+	assert.Equal(t, prov, p)
+
+	h, _ := mem.FindHistory(2022)
+	assert.Len(t, h, 1)
+	assert.Nil(t, h[0].Provenance, "provenance must be stored for the last revision only")
+
+	assert.NoError(t, mem.PutYear(2022, store.Months{1: {2: {Working: false, Type: store.Holiday}}}))
+	_, ok = mem.FindProvenance(2022)
+	assert.False(t, ok)
+}
@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/nvkalinin/business-calendar/log"
+)
+
+// Postgres хранит календари в PostgreSQL, по одной строке на каждый день, чтобы дни можно было запрашивать
+// напрямую через SQL, а несколько экземпляров сервиса могли работать с одной БД. Таблицы описаны в sql.go.
+type Postgres struct {
+	sqlStore
+}
+
+// postgresMigrations — изменения схемы БД по порядку, см. sqlDialect.migrations.
+var postgresMigrations = []string{
+	`CREATE TABLE cal_day (
+		calendar    text    NOT NULL,
+		date        date    NOT NULL,
+		week_day    text    NOT NULL,
+		working     boolean NOT NULL,
+		type        text    NOT NULL,
+		description text    NOT NULL,
+		provisional boolean NOT NULL,
+		PRIMARY KEY (calendar, date)
+	);
+	CREATE TABLE cal_year (
+		calendar text        NOT NULL,
+		year     integer     NOT NULL,
+		modified timestamptz NOT NULL,
+		hash     text        NOT NULL,
+		status   text        NOT NULL,
+		PRIMARY KEY (calendar, year)
+	);
+	CREATE TABLE cal_revision (
+		id       bigserial   PRIMARY KEY,
+		calendar text        NOT NULL,
+		year     integer     NOT NULL,
+		saved_at timestamptz NOT NULL,
+		hash     text        NOT NULL,
+		data     jsonb       NOT NULL
+	);
+	CREATE INDEX cal_revision_year ON cal_revision (calendar, year, saved_at);
+	CREATE TABLE cal_day_source (
+		calendar    text NOT NULL,
+		date        date NOT NULL,
+		week_day    text NOT NULL,
+		working     text NOT NULL,
+		type        text NOT NULL,
+		description text NOT NULL,
+		PRIMARY KEY (calendar, date)
+	);`,
+}
+
+// postgresMigrationLock — ключ advisory-блокировки, под которой применяются миграции, чтобы экземпляры
+// сервиса, запущенные одновременно, не применяли их параллельно.
+const postgresMigrationLock int64 = 0x62697a63616c // "bizcal"
+
+var postgresDialect = &sqlDialect{
+	name:       "postgres",
+	migrations: postgresMigrations,
+	migrationTable: `CREATE TABLE IF NOT EXISTS cal_schema_migration (
+		version integer     PRIMARY KEY,
+		applied timestamptz NOT NULL DEFAULT now()
+	)`,
+	lockMigrations: fmt.Sprintf(`SELECT pg_advisory_xact_lock(%d)`, postgresMigrationLock),
+	// Экземпляры сервиса, синхронизирующие один и тот же год, сохраняют его по очереди.
+	lockYear: `SELECT pg_advisory_xact_lock(hashtext(?), ?)`,
+	numbered: true,
+	timeArg:  func(t time.Time) interface{} { return t },
+	insert: func(tx *sql.Tx, table string, columns ...string) (*sql.Stmt, error) {
+		return tx.Prepare(pq.CopyIn(table, columns...))
+	},
+	flush: true,
+}
+
+func NewPostgres(dsn string) (*Postgres, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open postgres store: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("cannot connect to postgres: %w", err)
+	}
+	if err := migrateSQL(db, postgresDialect); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	log.Printf("[DEBUG] store/postgres opened successfully")
+
+	return &Postgres{sqlStore{db: db, d: postgresDialect}}, nil
+}
+
+// Calendar возвращает хранилище именованного календаря (например, регионального) в той же БД.
+// Закрывать его не нужно, достаточно закрыть основное хранилище.
+func (p *Postgres) Calendar(name string) *Postgres {
+	return &Postgres{*p.withCalendar(name)}
+}
@@ -0,0 +1,520 @@
+package engine
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/log"
+	"github.com/nvkalinin/business-calendar/store"
+)
+
+// Общий код SQL-хранилищ (Postgres, SQLite): дни хранятся по одной строке, дата — в формате store.DateLayout.
+//
+// Таблицы:
+//   - cal_day — дни календаря (store.Day), ключ — (calendar, date);
+//   - cal_year — метаданные годов (store.YearMeta), ключ — (calendar, year);
+//   - cal_revision — версии годов (store.Revision) в виде JSON, новая версия добавляется, только если
+//     содержимое года изменилось;
+//   - cal_day_source — источники полей дней последней версии года (store.Provenance), ключ — (calendar, date).
+//
+// Колонка calendar содержит название именованного календаря (см. метод Calendar), у основного календаря
+// она пустая. Схема создается и обновляется при открытии хранилища (см. migrateSQL).
+
+// sqlTimeLayout — формат времени для СУБД без типа времени (SQLite): фиксированной длины, в UTC, поэтому
+// порядок строк совпадает с порядком времени.
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// sqlDialect описывает, чем отличаются СУБД. Запросы sqlStore пишутся с параметрами ? и общим для всех СУБД
+// синтаксисом.
+type sqlDialect struct {
+	name string // Название СУБД для логов и ошибок.
+
+	// migrations — изменения схемы БД по порядку, версия схемы — номер последней примененной миграции
+	// (индекс + 1). Примененные миграции менять нельзя, новые добавляются в конец.
+	migrations     []string
+	migrationTable string // Создание таблицы cal_schema_migration, в которой хранятся версии схемы.
+
+	// lockMigrations и lockYear — блокировки транзакции, под которыми применяются миграции и сохраняется год
+	// (параметры: название календаря и год), чтобы несколько экземпляров сервиса не делали это параллельно.
+	// Пустые, если СУБД и так выполняет транзакции по очереди.
+	lockMigrations string
+	lockYear       string
+
+	numbered bool                          // Параметры нумеруются ($1, $2, ...) вместо ?.
+	timeArg  func(t time.Time) interface{} // Значение параметра для колонки времени.
+	insert   func(tx *sql.Tx, table string, columns ...string) (*sql.Stmt, error)
+	flush    bool // После вставки строк через insert нужен stmt.Exec() без параметров.
+}
+
+// rebind заменяет параметры ? в запросе q на параметры СУБД.
+func (d *sqlDialect) rebind(q string) string {
+	if !d.numbered {
+		return q
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, r := range q {
+		if r != '?' {
+			sb.WriteRune(r)
+			continue
+		}
+		n++
+		sb.WriteByte('$')
+		sb.WriteString(strconv.Itoa(n))
+	}
+	return sb.String()
+}
+
+// insertSQL возвращает запрос INSERT в таблицу table с параметрами для колонок columns.
+func insertSQL(table string, columns ...string) string {
+	params := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), params)
+}
+
+// migrateSQL применяет к db миграции d, которые еще не применены.
+func migrateSQL(db *sql.DB, d *sqlDialect) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s cannot begin migration: %w", d.name, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if d.lockMigrations != "" {
+		if _, err := tx.Exec(d.lockMigrations); err != nil {
+			return fmt.Errorf("%s cannot lock migrations: %w", d.name, err)
+		}
+	}
+	if _, err := tx.Exec(d.migrationTable); err != nil {
+		return fmt.Errorf("%s cannot create migrations table: %w", d.name, err)
+	}
+
+	var version int
+	if err := tx.QueryRow(`SELECT coalesce(max(version), 0) FROM cal_schema_migration`).Scan(&version); err != nil {
+		return fmt.Errorf("%s cannot get schema version: %w", d.name, err)
+	}
+	if version > len(d.migrations) {
+		log.Printf("[WARN] store/%s schema version %d is newer than supported %d", d.name, version, len(d.migrations))
+	}
+
+	for i := version; i < len(d.migrations); i++ {
+		if _, err := tx.Exec(d.migrations[i]); err != nil {
+			return fmt.Errorf("%s cannot apply migration %d: %w", d.name, i+1, err)
+		}
+		if _, err := tx.Exec(d.rebind(`INSERT INTO cal_schema_migration (version) VALUES (?)`), i+1); err != nil {
+			return fmt.Errorf("%s cannot save migration %d: %w", d.name, i+1, err)
+		}
+		log.Printf("[INFO] store/%s applied migration %d", d.name, i+1)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s cannot commit migrations: %w", d.name, err)
+	}
+	return nil
+}
+
+// sqlStore — хранилище календаря в SQL-БД, общая часть Postgres и SQLite.
+type sqlStore struct {
+	db  *sql.DB
+	cal string // Название именованного календаря, у основного календаря — пустое.
+	d   *sqlDialect
+}
+
+// withCalendar возвращает хранилище именованного календаря name в той же БД.
+func (s *sqlStore) withCalendar(name string) *sqlStore {
+	return &sqlStore{db: s.db, cal: name, d: s.d}
+}
+
+func (s *sqlStore) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("cannot close %s store: %w", s.d.name, err)
+	}
+	log.Printf("[DEBUG] store/%s closed successfully", s.d.name)
+	return nil
+}
+
+func (s *sqlStore) FindDay(y int, mon time.Month, d int) (*store.Day, bool) {
+	date, ok := sqlDate(y, mon, d)
+	if !ok {
+		return nil, false
+	}
+
+	months, ok := s.findDays(date, date)
+	if !ok {
+		return nil, false
+	}
+
+	day := months[mon][d]
+	return &day, true
+}
+
+func (s *sqlStore) FindMonth(y int, mon time.Month) (store.Days, bool) {
+	from, _ := sqlDate(y, mon, 1)
+	to, _ := sqlDate(y, mon, daysIn(y, mon))
+
+	months, ok := s.findDays(from, to)
+	if !ok {
+		return nil, false
+	}
+	return months[mon], true
+}
+
+func (s *sqlStore) FindYear(y int) (store.Months, bool) {
+	return s.findDays(sqlYear(y))
+}
+
+// findDays возвращает дни с from по to включительно (даты в формате store.DateLayout).
+func (s *sqlStore) findDays(from, to string) (store.Months, bool) {
+	log.Printf("[DEBUG] store/%s find days calendar=%q from=%s to=%s", s.d.name, s.cal, from, to)
+	rows, err := s.db.Query(s.d.rebind(`
+		SELECT date, week_day, working, type, description, provisional
+		FROM cal_day
+		WHERE calendar = ? AND date BETWEEN ? AND ?`),
+		s.cal, from, to)
+	if err != nil {
+		log.Printf("[WARN] %s: cannot find days %s..%s: %v", s.d.name, from, to, err)
+		return nil, false
+	}
+	defer rows.Close()
+
+	months, err := scanDays(rows)
+	if err != nil {
+		log.Printf("[WARN] %s: cannot read days %s..%s: %v", s.d.name, from, to, err)
+		return nil, false
+	}
+	return months, len(months) > 0
+}
+
+func (s *sqlStore) FindYearMeta(y int) (*store.YearMeta, bool) {
+	meta, err := s.findYearMeta(s.db.QueryRow(s.d.rebind(`SELECT modified, hash, status FROM cal_year WHERE calendar = ? AND year = ?`), s.cal, y))
+	if err != nil {
+		log.Printf("[WARN] %s: cannot find meta of %d: %v", s.d.name, y, err)
+		return nil, false
+	}
+	return meta, meta != nil
+}
+
+// findYearMeta читает метаданные года из row или возвращает nil, если года нет.
+func (s *sqlStore) findYearMeta(row *sql.Row) (*store.YearMeta, error) {
+	var (
+		meta     store.YearMeta
+		modified sqlTime
+	)
+	err := row.Scan(&modified, &meta.Hash, &meta.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	meta.Modified = time.Time(modified).UTC()
+	return &meta, nil
+}
+
+// FindHistory возвращает все версии года в порядке сохранения.
+func (s *sqlStore) FindHistory(y int) ([]store.Revision, bool) {
+	rows, err := s.db.Query(s.d.rebind(`SELECT data FROM cal_revision WHERE calendar = ? AND year = ? ORDER BY saved_at, id`), s.cal, y)
+	if err != nil {
+		log.Printf("[WARN] %s: cannot find history of %d: %v", s.d.name, y, err)
+		return nil, false
+	}
+	defer rows.Close()
+
+	h, err := scanHistory(rows)
+	if err != nil {
+		log.Printf("[WARN] %s: cannot read history of %d: %v", s.d.name, y, err)
+		return nil, false
+	}
+	return h, len(h) > 0
+}
+
+// FindProvenance возвращает источники полей каждого дня года, сохраненные вместе с последней версией.
+func (s *sqlStore) FindProvenance(y int) (store.Provenance, bool) {
+	from, to := sqlYear(y)
+	rows, err := s.db.Query(s.d.rebind(`
+		SELECT date, week_day, working, type, description
+		FROM cal_day_source
+		WHERE calendar = ? AND date BETWEEN ? AND ?`),
+		s.cal, from, to)
+	if err != nil {
+		log.Printf("[WARN] %s: cannot find provenance of %d: %v", s.d.name, y, err)
+		return nil, false
+	}
+	defer rows.Close()
+
+	prov, err := scanProvenance(rows)
+	if err != nil {
+		log.Printf("[WARN] %s: cannot read provenance of %d: %v", s.d.name, y, err)
+		return nil, false
+	}
+	return prov, len(prov) > 0
+}
+
+func (s *sqlStore) PutYear(y int, data store.Months) error {
+	return s.PutRevision(y, store.NewRevision(data, nil))
+}
+
+// PutRevision сохраняет год так же, как PutYear, и добавляет rev в историю, если содержимое года изменилось.
+// rev должна быть создана через store.NewRevision. Дни года и источники их полей (rev.Provenance) заменяются всегда.
+func (s *sqlStore) PutRevision(y int, rev store.Revision) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s cannot begin transaction: %w", s.d.name, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if s.d.lockYear != "" {
+		if _, err := tx.Exec(s.d.rebind(s.d.lockYear), s.cal, y); err != nil {
+			return fmt.Errorf("%s cannot lock year %d: %w", s.d.name, y, err)
+		}
+	}
+
+	if err := s.putYearMeta(tx, y, rev.Months); err != nil {
+		return err
+	}
+	if err := s.putDays(tx, y, rev.Months); err != nil {
+		return err
+	}
+	if err := s.putRevision(tx, y, rev); err != nil {
+		return err
+	}
+	if err := s.putProvenance(tx, y, rev.Provenance); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s cannot commit year %d: %w", s.d.name, y, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) putYearMeta(tx *sql.Tx, y int, data store.Months) error {
+	prev, err := s.findYearMeta(tx.QueryRow(s.d.rebind(`SELECT modified, hash, status FROM cal_year WHERE calendar = ? AND year = ?`), s.cal, y))
+	if err != nil {
+		return fmt.Errorf("%s cannot get meta of %d: %w", s.d.name, y, err)
+	}
+
+	meta := store.NextYearMeta(prev, data)
+	_, err = tx.Exec(s.d.rebind(`
+		INSERT INTO cal_year (calendar, year, modified, hash, status) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (calendar, year) DO UPDATE SET modified = EXCLUDED.modified, hash = EXCLUDED.hash, status = EXCLUDED.status`),
+		s.cal, y, s.d.timeArg(meta.Modified), meta.Hash, meta.Status)
+	if err != nil {
+		return fmt.Errorf("%s cannot put meta of %d: %w", s.d.name, y, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) putDays(tx *sql.Tx, y int, data store.Months) error {
+	from, to := sqlYear(y)
+	if _, err := tx.Exec(s.d.rebind(`DELETE FROM cal_day WHERE calendar = ? AND date BETWEEN ? AND ?`), s.cal, from, to); err != nil {
+		return fmt.Errorf("%s cannot delete days of %d: %w", s.d.name, y, err)
+	}
+
+	stmt, err := s.d.insert(tx, "cal_day", "calendar", "date", "week_day", "working", "type", "description", "provisional")
+	if err != nil {
+		return fmt.Errorf("%s cannot prepare days of %d: %w", s.d.name, y, err)
+	}
+	defer stmt.Close()
+
+	for mon, days := range data {
+		for d, day := range days {
+			date, ok := sqlDate(y, mon, d)
+			if !ok {
+				return fmt.Errorf("%s invalid date %d-%02d-%02d", s.d.name, y, mon, d)
+			}
+
+			_, err := stmt.Exec(s.cal, date, day.WeekDay, day.Working, day.Type, day.Desc, day.Provisional)
+			if err != nil {
+				return fmt.Errorf("%s cannot put day %s: %w", s.d.name, date, err)
+			}
+		}
+	}
+	if s.d.flush {
+		if _, err := stmt.Exec(); err != nil {
+			return fmt.Errorf("%s cannot put days of %d: %w", s.d.name, y, err)
+		}
+	}
+	log.Printf("[DEBUG] store/%s put days calendar=%q year=%d", s.d.name, s.cal, y)
+	return nil
+}
+
+func (s *sqlStore) putRevision(tx *sql.Tx, y int, rev store.Revision) error {
+	var lastHash string
+	err := tx.QueryRow(s.d.rebind(`SELECT hash FROM cal_revision WHERE calendar = ? AND year = ? ORDER BY saved_at DESC, id DESC LIMIT 1`),
+		s.cal, y).Scan(&lastHash)
+	switch {
+	case err == nil:
+		if lastHash == rev.Hash {
+			return nil
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%s cannot get last revision of %d: %w", s.d.name, y, err)
+	}
+
+	rev.Provenance = nil // Источники полей дней хранятся только для последней версии (cal_day_source).
+	data, err := json.Marshal(rev)
+	if err != nil {
+		return fmt.Errorf("%s cannot marshal revision of %d: %w", s.d.name, y, err)
+	}
+
+	_, err = tx.Exec(s.d.rebind(`INSERT INTO cal_revision (calendar, year, saved_at, hash, data) VALUES (?, ?, ?, ?, ?)`),
+		s.cal, y, s.d.timeArg(rev.Time), rev.Hash, string(data)) // []byte Postgres принял бы как bytea.
+	if err != nil {
+		return fmt.Errorf("%s cannot put revision of %d: %w", s.d.name, y, err)
+	}
+	log.Printf("[DEBUG] store/%s put revision calendar=%q year=%d len=%d", s.d.name, s.cal, y, len(data))
+	return nil
+}
+
+func (s *sqlStore) putProvenance(tx *sql.Tx, y int, prov store.Provenance) error {
+	from, to := sqlYear(y)
+	if _, err := tx.Exec(s.d.rebind(`DELETE FROM cal_day_source WHERE calendar = ? AND date BETWEEN ? AND ?`), s.cal, from, to); err != nil {
+		return fmt.Errorf("%s cannot delete provenance of %d: %w", s.d.name, y, err)
+	}
+	if len(prov) == 0 {
+		return nil
+	}
+
+	stmt, err := s.d.insert(tx, "cal_day_source", "calendar", "date", "week_day", "working", "type", "description")
+	if err != nil {
+		return fmt.Errorf("%s cannot prepare provenance of %d: %w", s.d.name, y, err)
+	}
+	defer stmt.Close()
+
+	for mon, days := range prov {
+		for d, src := range days {
+			date, ok := sqlDate(y, mon, d)
+			if !ok {
+				return fmt.Errorf("%s invalid date %d-%02d-%02d", s.d.name, y, mon, d)
+			}
+			if _, err := stmt.Exec(s.cal, date, src.WeekDay, src.Working, src.Type, src.Desc); err != nil {
+				return fmt.Errorf("%s cannot put provenance %s: %w", s.d.name, date, err)
+			}
+		}
+	}
+	if s.d.flush {
+		if _, err := stmt.Exec(); err != nil {
+			return fmt.Errorf("%s cannot put provenance of %d: %w", s.d.name, y, err)
+		}
+	}
+	return nil
+}
+
+// sqlDate возвращает дату в формате store.DateLayout или false, если такой даты нет (например, 30 февраля).
+func sqlDate(y int, mon time.Month, d int) (string, bool) {
+	date := store.NewDate(y, mon, d)
+	if date.Year() != y || date.Month() != mon || date.Day() != d {
+		return "", false
+	}
+	return date.Format(store.DateLayout), true
+}
+
+// sqlYear возвращает первый и последний день года y в формате store.DateLayout.
+func sqlYear(y int) (from, to string) {
+	from, _ = sqlDate(y, time.January, 1)
+	to, _ = sqlDate(y, time.December, 31)
+	return from, to
+}
+
+func daysIn(y int, mon time.Month) int {
+	return store.NewDate(y, mon+1, 0).Day()
+}
+
+// sqlTime читает колонки даты и времени: драйвер Postgres возвращает их как time.Time, SQLite — как текст
+// в формате store.DateLayout (даты) или sqlTimeLayout (время).
+type sqlTime time.Time
+
+func (t *sqlTime) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case time.Time:
+		*t = sqlTime(v)
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("unsupported time value %T", src)
+	}
+
+	layout := sqlTimeLayout
+	if len(text) == len(store.DateLayout) {
+		layout = store.DateLayout
+	}
+	parsed, err := time.Parse(layout, text)
+	if err != nil {
+		return fmt.Errorf("invalid time '%s'", text)
+	}
+	*t = sqlTime(parsed)
+	return nil
+}
+
+// scanDays читает дни из строк с колонками date, week_day, working, type, description, provisional.
+func scanDays(rows *sql.Rows) (store.Months, error) {
+	months := make(store.Months)
+	for rows.Next() {
+		var (
+			date sqlTime
+			day  store.Day
+		)
+		if err := rows.Scan(&date, &day.WeekDay, &day.Working, &day.Type, &day.Desc, &day.Provisional); err != nil {
+			return nil, err
+		}
+
+		t := time.Time(date)
+		if months[t.Month()] == nil {
+			months[t.Month()] = make(store.Days)
+		}
+		months[t.Month()][t.Day()] = day
+	}
+	return months, rows.Err()
+}
+
+// scanProvenance читает источники полей дней из строк с колонками date, week_day, working, type, description.
+func scanProvenance(rows *sql.Rows) (store.Provenance, error) {
+	prov := make(store.Provenance)
+	for rows.Next() {
+		var (
+			date sqlTime
+			src  store.DaySource
+		)
+		if err := rows.Scan(&date, &src.WeekDay, &src.Working, &src.Type, &src.Desc); err != nil {
+			return nil, err
+		}
+
+		t := time.Time(date)
+		if prov[t.Month()] == nil {
+			prov[t.Month()] = make(map[int]store.DaySource)
+		}
+		prov[t.Month()][t.Day()] = src
+	}
+	return prov, rows.Err()
+}
+
+// scanHistory читает версии года из строк с одной колонкой — store.Revision в JSON.
+// Версии, которые не удалось разобрать, пропускаются.
+func scanHistory(rows *sql.Rows) ([]store.Revision, error) {
+	var h []store.Revision
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var rev store.Revision
+		if err := json.Unmarshal(data, &rev); err != nil {
+			log.Printf("[WARN] store: invalid revision: %v", err)
+			continue
+		}
+		h = append(h, rev)
+	}
+	return h, rows.Err()
+}
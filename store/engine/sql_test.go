@@ -0,0 +1,196 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Общие тесты SQL-хранилищ выполняются для каждой СУБД. Postgres пропускается, если не задана тестовая БД
+// (см. postgresTestDSN).
+var sqlTestStores = []struct {
+	name string
+	open func(t *testing.T) *sqlStore
+}{
+	{"sqlite", func(t *testing.T) *sqlStore { return &makeSQLite(t).sqlStore }},
+	{"postgres", func(t *testing.T) *sqlStore { return &makePostgres(t).sqlStore }},
+}
+
+func forEachSQLStore(t *testing.T, test func(t *testing.T, s *sqlStore)) {
+	for _, ts := range sqlTestStores {
+		ts := ts
+		t.Run(ts.name, func(t *testing.T) {
+			s := ts.open(t)
+			defer s.Close()
+			test(t, s)
+		})
+	}
+}
+
+func TestSQLStore(t *testing.T) {
+	forEachSQLStore(t, func(t *testing.T, s *sqlStore) {
+		err := s.PutYear(2022, sample2022)
+		require.NoError(t, err)
+
+		y, ok := s.FindYear(2022)
+		assert.True(t, ok)
+		assert.Equal(t, sample2022, y)
+
+		m, ok := s.FindMonth(2022, time.February)
+		assert.True(t, ok)
+		assert.Equal(t, sample2022[time.February], m)
+
+		d, ok := s.FindDay(2022, time.January, 2)
+		assert.True(t, ok)
+		assert.Equal(t, sample2022[time.January][2], *d)
+
+		_, ok = s.FindDay(2022, time.February, 30)
+		assert.False(t, ok)
+		_, ok = s.FindMonth(2022, time.March)
+		assert.False(t, ok)
+		_, ok = s.FindYear(2023)
+		assert.False(t, ok)
+
+		// Год заменяется целиком.
+		provisional := store.Months{time.March: {1: {WeekDay: store.Tuesday, Working: true, Type: store.Normal, Provisional: true}}}
+		require.NoError(t, s.PutYear(2022, provisional))
+		y, _ = s.FindYear(2022)
+		assert.Equal(t, provisional, y)
+
+		err = s.PutYear(2022, store.Months{time.February: {30: {Working: true}}})
+		assert.ErrorContains(t, err, "invalid date")
+	})
+}
+
+func TestSQLStore_FindYearMeta(t *testing.T) {
+	forEachSQLStore(t, func(t *testing.T, s *sqlStore) {
+		_, ok := s.FindYearMeta(2022)
+		assert.False(t, ok)
+
+		err := s.PutYear(2022, sample2022)
+		require.NoError(t, err)
+
+		meta, ok := s.FindYearMeta(2022)
+		assert.True(t, ok)
+		assert.Equal(t, sample2022.Hash(), meta.Hash)
+		assert.Equal(t, store.StatusConfirmed, meta.Status)
+		assert.False(t, meta.Modified.IsZero())
+
+		// Повторное сохранение того же календаря не меняет время изменения.
+		err = s.PutYear(2022, sample2022)
+		require.NoError(t, err)
+		meta2, _ := s.FindYearMeta(2022)
+		assert.Equal(t, meta.Modified.UnixNano(), meta2.Modified.UnixNano())
+	})
+}
+
+func TestSQLStore_Calendar(t *testing.T) {
+	forEachSQLStore(t, func(t *testing.T, s *sqlStore) {
+		reg := s.withCalendar("tatarstan")
+		err := reg.PutYear(2022, sample2022)
+		require.NoError(t, err)
+
+		_, ok := s.FindYear(2022)
+		assert.False(t, ok)
+		_, ok = s.FindYearMeta(2022)
+		assert.False(t, ok)
+
+		y, ok := s.withCalendar("tatarstan").FindYear(2022)
+		assert.True(t, ok)
+		assert.Equal(t, sample2022, y)
+
+		_, ok = s.withCalendar("bashkortostan").FindYear(2022)
+		assert.False(t, ok)
+	})
+}
+
+func TestSQLStore_FindHistory(t *testing.T) {
+	forEachSQLStore(t, func(t *testing.T, s *sqlStore) {
+		_, ok := s.FindHistory(2022)
+		assert.False(t, ok)
+
+		require.NoError(t, s.PutRevision(2022, store.NewRevision(sample2022, []string{"generic"})))
+		require.NoError(t, s.PutYear(2022, sample2022)) // Без изменений.
+		require.NoError(t, s.PutYear(2021, sample2022))
+
+		changed := sample2022.Copy()
+		changed[time.February][1] = store.Day{WeekDay: store.Tuesday, Working: false, Type: store.Holiday}
+		require.NoError(t, s.PutRevision(2022, store.NewRevision(changed, []string{"generic", "override"})))
+
+		h, ok := s.FindHistory(2022)
+		require.True(t, ok)
+		require.Len(t, h, 2)
+		assert.Equal(t, []string{"generic"}, h[0].Sources)
+		assert.Equal(t, sample2022, h[0].Months)
+		assert.Equal(t, []string{"generic", "override"}, h[1].Sources)
+		assert.Equal(t, changed, h[1].Months)
+		assert.True(t, h[0].Time.Before(h[1].Time))
+
+		h, _ = s.FindHistory(2021)
+		assert.Len(t, h, 1)
+
+		// Возврат к прежнему содержимому — тоже новая версия.
+		require.NoError(t, s.PutYear(2022, sample2022))
+		h, _ = s.FindHistory(2022)
+		assert.Len(t, h, 3)
+	})
+}
+
+func TestSQLStore_FindProvenance(t *testing.T) {
+	forEachSQLStore(t, func(t *testing.T, s *sqlStore) {
+		_, ok := s.FindProvenance(2022)
+		assert.False(t, ok)
+
+		prov := store.Provenance{time.February: {1: {WeekDay: "generic", Working: "consultant", Type: "consultant"}}}
+		rev := store.NewRevision(sample2022, []string{"generic", "consultant"})
+		rev.Provenance = prov
+		require.NoError(t, s.PutRevision(2022, rev))
+
+		pr, ok := s.FindProvenance(2022)
+		require.True(t, ok)
+		assert.Equal(t, prov, pr)
+
+		h, _ := s.FindHistory(2022)
+		require.Len(t, h, 1)
+		assert.Nil(t, h[0].Provenance, "provenance must be stored for the last revision only")
+
+		_, ok = s.withCalendar("tatarstan").FindProvenance(2022)
+		assert.False(t, ok)
+
+		// Сохранение без сведений об источниках удаляет прежние.
+		require.NoError(t, s.PutYear(2022, sample2022))
+		_, ok = s.FindProvenance(2022)
+		assert.False(t, ok)
+	})
+}
+
+func TestSqlDate(t *testing.T) {
+	date, ok := sqlDate(2024, time.February, 29)
+	assert.True(t, ok)
+	assert.Equal(t, "2024-02-29", date)
+
+	_, ok = sqlDate(2022, time.February, 29)
+	assert.False(t, ok)
+	_, ok = sqlDate(2022, time.January, 0)
+	assert.False(t, ok)
+
+	assert.Equal(t, 28, daysIn(2022, time.February))
+	assert.Equal(t, 31, daysIn(2022, time.December))
+}
+
+func TestSqlYear(t *testing.T) {
+	from, to := sqlYear(2022)
+	assert.Equal(t, "2022-01-01", from)
+	assert.Equal(t, "2022-12-31", to)
+}
+
+func TestSqlDialect_rebind(t *testing.T) {
+	q := `SELECT data FROM cal_revision WHERE calendar = ? AND year = ?`
+	assert.Equal(t, q, sqliteDialect.rebind(q))
+	assert.Equal(t, `SELECT data FROM cal_revision WHERE calendar = $1 AND year = $2`, postgresDialect.rebind(q))
+
+	assert.Equal(t, "INSERT INTO cal_day (calendar, date) VALUES (?, ?)", insertSQL("cal_day", "calendar", "date"))
+}
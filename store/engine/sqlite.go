@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nvkalinin/business-calendar/log"
+	_ "modernc.org/sqlite" // Драйвер без cgo.
+)
+
+// SQLite хранит календари в файле SQLite, по одной строке на каждый день, так же как Postgres: файл можно
+// открыть стандартными инструментами (sqlite3) и запрашивать дни через SQL.
+//
+// Таблицы и колонки совпадают с Postgres, даты хранятся в виде текста в формате store.DateLayout, время —
+// в формате sqlTimeLayout (UTC), поэтому их можно сравнивать как строки.
+//
+// Запись в SQLite возможна только из одного соединения, поэтому все запросы выполняются через одно соединение.
+type SQLite struct {
+	sqlStore
+}
+
+// sqliteMigrations — изменения схемы БД по порядку, см. sqlDialect.migrations.
+var sqliteMigrations = []string{
+	`CREATE TABLE cal_day (
+		calendar    TEXT    NOT NULL,
+		date        TEXT    NOT NULL,
+		week_day    TEXT    NOT NULL,
+		working     INTEGER NOT NULL,
+		type        TEXT    NOT NULL,
+		description TEXT    NOT NULL,
+		provisional INTEGER NOT NULL,
+		PRIMARY KEY (calendar, date)
+	) WITHOUT ROWID;
+	CREATE TABLE cal_year (
+		calendar TEXT    NOT NULL,
+		year     INTEGER NOT NULL,
+		modified TEXT    NOT NULL,
+		hash     TEXT    NOT NULL,
+		status   TEXT    NOT NULL,
+		PRIMARY KEY (calendar, year)
+	) WITHOUT ROWID;
+	CREATE TABLE cal_revision (
+		id       INTEGER PRIMARY KEY,
+		calendar TEXT    NOT NULL,
+		year     INTEGER NOT NULL,
+		saved_at TEXT    NOT NULL,
+		hash     TEXT    NOT NULL,
+		data     TEXT    NOT NULL
+	);
+	CREATE INDEX cal_revision_year ON cal_revision (calendar, year, saved_at);
+	CREATE TABLE cal_day_source (
+		calendar    TEXT NOT NULL,
+		date        TEXT NOT NULL,
+		week_day    TEXT NOT NULL,
+		working     TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		description TEXT NOT NULL,
+		PRIMARY KEY (calendar, date)
+	) WITHOUT ROWID;`,
+}
+
+var sqliteDialect = &sqlDialect{
+	name:       "sqlite",
+	migrations: sqliteMigrations,
+	migrationTable: `CREATE TABLE IF NOT EXISTS cal_schema_migration (
+		version INTEGER PRIMARY KEY,
+		applied TEXT    NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	timeArg: func(t time.Time) interface{} { return t.UTC().Format(sqlTimeLayout) },
+	insert: func(tx *sql.Tx, table string, columns ...string) (*sql.Stmt, error) {
+		return tx.Prepare(insertSQL(table, columns...))
+	},
+}
+
+func NewSQLite(file string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", file+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("cannot open sqlite store: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("cannot open sqlite store: %w", err)
+	}
+	if err := migrateSQL(db, sqliteDialect); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	log.Printf("[DEBUG] store/sqlite opened %s successfully", file)
+
+	return &SQLite{sqlStore{db: db, d: sqliteDialect}}, nil
+}
+
+// Calendar возвращает хранилище именованного календаря (например, регионального) в том же файле БД.
+// Закрывать его не нужно, достаточно закрыть основное хранилище.
+func (s *SQLite) Calendar(name string) *SQLite {
+	return &SQLite{*s.withCalendar(name)}
+}
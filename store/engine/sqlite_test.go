@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nvkalinin/business-calendar/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Основные тесты SQLite — в sql_test.go.
+
+func TestSQLite_reopen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cal.sqlite")
+
+	s, err := NewSQLite(file)
+	require.NoError(t, err)
+	require.NoError(t, s.PutRevision(2022, store.NewRevision(sample2022, []string{"generic"})))
+	meta, _ := s.FindYearMeta(2022)
+	require.NoError(t, s.Close())
+
+	// Миграции не применяются повторно, данные сохраняются.
+	s, err = NewSQLite(file)
+	require.NoError(t, err)
+	defer s.Close()
+
+	y, ok := s.FindYear(2022)
+	assert.True(t, ok)
+	assert.Equal(t, sample2022, y)
+
+	meta2, ok := s.FindYearMeta(2022)
+	assert.True(t, ok)
+	assert.True(t, meta.Modified.Equal(meta2.Modified))
+
+	h, _ := s.FindHistory(2022)
+	assert.Len(t, h, 1)
+}
+
+func makeSQLite(t *testing.T) *SQLite {
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "cal.sqlite"))
+	require.NoError(t, err)
+	return s
+}